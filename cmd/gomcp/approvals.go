@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gomcp-pilot/internal/approval"
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/store"
+)
+
+// adminClient talks to a running gateway's HTTP admin API.
+type adminClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// adminFlags are the connection flags shared by the admin subcommands.
+type adminFlags struct {
+	url, token string
+	// caCert, cert and key configure HTTPS: a CA bundle to trust beyond the
+	// system roots, and a client certificate for mutual TLS.
+	caCert, cert, key string
+}
+
+func (f *adminFlags) register(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&f.url, "url", "", "gateway base URL (default: the listen address from config)")
+	cmd.PersistentFlags().StringVar(&f.token, "token", "", "bearer token (default auth_token from config)")
+	cmd.PersistentFlags().StringVar(&f.caCert, "cacert", "", "PEM CA bundle to trust (default tls_cert from config when it serves HTTPS)")
+	cmd.PersistentFlags().StringVar(&f.cert, "cert", "", "client certificate for mutual TLS")
+	cmd.PersistentFlags().StringVar(&f.key, "key", "", "client key for mutual TLS")
+}
+
+func newAdminClient(cfgPath string, f adminFlags) (*adminClient, error) {
+	var socket string
+	if f.url == "" || f.token == "" {
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return nil, err
+		}
+		if f.url == "" {
+			scheme := "http"
+			if cfg.TLS() {
+				scheme = "https"
+				if f.caCert == "" {
+					// Lets a self-signed gateway certificate verify.
+					f.caCert = cfg.TLSCert
+				}
+			}
+			network, addr := cfg.Listen()
+			host := "localhost"
+			if network == "unix" {
+				socket = addr
+			} else if h, port, err := net.SplitHostPort(addr); err == nil {
+				if h != "" && h != "0.0.0.0" && h != "::" {
+					host = h
+				}
+				host = net.JoinHostPort(host, port)
+			}
+			f.url = fmt.Sprintf("%s://%s", scheme, host)
+		}
+		if f.token == "" {
+			f.token = cfg.AuthToken
+		}
+	}
+	tlsCfg, err := f.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{TLSClientConfig: tlsCfg, Proxy: http.ProxyFromEnvironment}
+	if socket != "" {
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+	}
+	return &adminClient{
+		baseURL: strings.TrimRight(f.url, "/"),
+		token:   f.token,
+		http:    &http.Client{Timeout: 10 * time.Second, Transport: transport},
+	}, nil
+}
+
+func (f adminFlags) tlsConfig() (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if f.caCert != "" {
+		pem, err := os.ReadFile(f.caCert)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s contains no PEM certificates", f.caCert)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if (f.cert == "") != (f.key == "") {
+		return nil, fmt.Errorf("--cert and --key must be given together")
+	}
+	if f.cert != "" {
+		pair, err := tls.LoadX509KeyPair(f.cert, f.key)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.Certificates = []tls.Certificate{pair}
+	}
+	return tlsCfg, nil
+}
+
+// do sends body, if non-nil, as JSON and decodes the response into out.
+func (c *adminClient) do(method, path string, body, out any) error {
+	var payload io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, payload)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func approvalsCmd(cfgPath *string) *cobra.Command {
+	var flags adminFlags
+	cmd := &cobra.Command{
+		Use:   "approvals",
+		Short: "List and resolve tool calls waiting for approval (approval_mode: queue)",
+	}
+	flags.register(cmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "list",
+		Short:        "List pending approvals",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newAdminClient(*cfgPath, flags)
+			if err != nil {
+				return err
+			}
+			var resp struct {
+				Approvals []approval.Request `json:"approvals"`
+			}
+			if err := c.do(http.MethodGet, "/approvals", nil, &resp); err != nil {
+				return err
+			}
+			if len(resp.Approvals) == 0 {
+				fmt.Println("No pending approvals.")
+				return nil
+			}
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tAGE\tEXPIRES IN\tUPSTREAM\tTOOL\tARGUMENTS")
+			for _, a := range resp.Approvals {
+				age := time.Since(a.CreatedAt).Round(time.Second)
+				expires := "-"
+				if a.ExpiresAt != nil {
+					expires = time.Until(*a.ExpiresAt).Round(time.Second).String()
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", a.ID, age, expires, a.Upstream, a.Tool, a.Arguments)
+			}
+			return tw.Flush()
+		},
+	})
+
+	var grant, arguments string
+	for decision, done := range map[string]string{"approve": "approved", "deny": "denied"} {
+		sub := &cobra.Command{
+			Use:          decision + " <id>...",
+			Short:        strings.ToUpper(decision[:1]) + decision[1:] + " pending tool calls",
+			Args:         cobra.MinimumNArgs(1),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				c, err := newAdminClient(*cfgPath, flags)
+				if err != nil {
+					return err
+				}
+				query := ""
+				if decision == "approve" && grant != "" {
+					query = "?grant=" + url.QueryEscape(grant)
+				}
+				var body any
+				if decision == "approve" && arguments != "" {
+					if len(args) != 1 {
+						return fmt.Errorf("--arguments applies to a single approval")
+					}
+					if !json.Valid([]byte(arguments)) {
+						return fmt.Errorf("--arguments is not valid JSON")
+					}
+					body = map[string]json.RawMessage{"arguments": json.RawMessage(arguments)}
+				}
+				for _, id := range args {
+					if err := c.do(http.MethodPost, "/approvals/"+url.PathEscape(id)+"/"+decision+query, body, nil); err != nil {
+						return err
+					}
+					fmt.Printf("%s: %s\n", id, done)
+				}
+				return nil
+			},
+		}
+		if decision == "approve" {
+			sub.Flags().StringVar(&grant, "grant", "", "also remember the approval: tool (always), hour (for 1 hour) or args (identical arguments)")
+			sub.Flags().StringVar(&arguments, "arguments", "", "approve with these JSON arguments instead of the requested ones")
+		}
+		cmd.AddCommand(sub)
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "grants",
+		Short:        "List standing approval grants",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newAdminClient(*cfgPath, flags)
+			if err != nil {
+				return err
+			}
+			var resp struct {
+				Grants []store.Grant `json:"grants"`
+			}
+			if err := c.do(http.MethodGet, "/grants", nil, &resp); err != nil {
+				return err
+			}
+			if len(resp.Grants) == 0 {
+				fmt.Println("No grants.")
+				return nil
+			}
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tUPSTREAM\tTOOL\tEXPIRES IN\tARGUMENTS")
+			for _, g := range resp.Grants {
+				expires, args := "never", "*"
+				if g.ExpiresAt != nil {
+					expires = time.Until(*g.ExpiresAt).Round(time.Second).String()
+				}
+				if g.Arguments != "" {
+					args = g.Arguments
+				}
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", g.ID, g.Upstream, g.Tool, expires, args)
+			}
+			return tw.Flush()
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "revoke <grant-id>...",
+		Short:        "Revoke standing approval grants",
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newAdminClient(*cfgPath, flags)
+			if err != nil {
+				return err
+			}
+			for _, id := range args {
+				if err := c.do(http.MethodDelete, "/grants/"+url.PathEscape(id), nil, nil); err != nil {
+					return err
+				}
+				fmt.Printf("%s: revoked\n", id)
+			}
+			return nil
+		},
+	})
+	return cmd
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/store"
+)
+
+// auditPage is one response of GET /audit/calls.
+type auditPage struct {
+	Calls      []store.CallRecord `json:"calls"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+func auditCmd(cfgPath *string) *cobra.Command {
+	var flags adminFlags
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Query the audit log of a running gateway or an audit database file",
+	}
+	flags.register(cmd)
+
+	var (
+		filter       store.CallFilter
+		since, until string
+		cursor       string
+		asJSON, all  bool
+		listDB       string
+	)
+	list := &cobra.Command{
+		Use:          "list",
+		Short:        "List recorded operations, newest first",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var fetch func(cursor string) (auditPage, error)
+			var err error
+			if listDB != "" {
+				if fetch, err = queryOffline(filter, since, until); err != nil {
+					return err
+				}
+				if err := store.OpenReadOnly(listDB); err != nil {
+					return err
+				}
+				defer store.Close()
+			} else {
+				c, err := newAdminClient(*cfgPath, flags)
+				if err != nil {
+					return err
+				}
+				q := url.Values{}
+				set := func(k, v string) {
+					if v != "" {
+						q.Set(k, v)
+					}
+				}
+				set("operation", filter.Operation)
+				set("upstream", filter.Upstream)
+				set("tool", filter.Tool)
+				set("status", filter.Status)
+				set("caller", filter.Caller)
+				set("q", filter.Search)
+				set("since", since)
+				set("until", until)
+				if filter.Limit > 0 {
+					q.Set("limit", strconv.Itoa(filter.Limit))
+				}
+				fetch = func(cursor string) (auditPage, error) {
+					set("cursor", cursor)
+					var page auditPage
+					err := c.do(http.MethodGet, "/audit/calls?"+q.Encode(), nil, &page)
+					return page, err
+				}
+			}
+
+			var page auditPage
+			var calls []store.CallRecord
+			for {
+				if page, err = fetch(cursor); err != nil {
+					return err
+				}
+				calls = append(calls, page.Calls...)
+				if !all || page.NextCursor == "" {
+					break
+				}
+				cursor = page.NextCursor
+			}
+			if all {
+				page.NextCursor = ""
+			}
+
+			if asJSON {
+				if calls == nil {
+					calls = []store.CallRecord{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(auditPage{Calls: calls, NextCursor: page.NextCursor})
+			}
+			if len(calls) == 0 {
+				fmt.Println("No matching operations.")
+				return nil
+			}
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tTIME\tCALLER\tOPERATION\tUPSTREAM\tTARGET\tSTATUS\tDURATION\tARGUMENTS")
+			for _, r := range calls {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%dms\t%s\n", r.ID, r.Timestamp.Local().Format("2006-01-02 15:04:05"),
+					orDash(r.Caller), r.Operation, orDash(r.Upstream), orDash(r.Tool), r.Status, r.DurationMs, truncate(r.Arguments, 60))
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+			if page.NextCursor != "" {
+				fmt.Fprintf(os.Stderr, "More results: --cursor %s (or --all)\n", page.NextCursor)
+			}
+			return nil
+		},
+	}
+	list.Flags().StringVar(&filter.Operation, "operation", "", "only this operation: tools/call, resources/read, prompts/get, tools/list, ...")
+	list.Flags().StringVar(&filter.Upstream, "upstream", "", "only calls to this upstream")
+	list.Flags().StringVar(&filter.Tool, "tool", "", "only calls to this tool, prompt or resource URI")
+	list.Flags().StringVar(&filter.Status, "status", "", "only calls with this status: success, error, cancelled or timeout")
+	list.Flags().StringVar(&filter.Caller, "caller", "", "only calls made with this token")
+	list.Flags().StringVarP(&filter.Search, "search", "q", "", "only calls whose arguments contain this text")
+	list.Flags().StringVar(&since, "since", "", "only calls at or after this time (RFC 3339, or a duration such as 24h)")
+	list.Flags().StringVar(&until, "until", "", "only calls before this time (RFC 3339, or a duration such as 1h)")
+	list.Flags().IntVar(&filter.Limit, "limit", 0, fmt.Sprintf("calls per page (default %d, max %d)", store.DefaultQueryLimit, store.MaxQueryLimit))
+	list.Flags().StringVar(&cursor, "cursor", "", "continue from the cursor a previous page printed")
+	list.Flags().BoolVar(&all, "all", false, "fetch every page")
+	list.Flags().BoolVar(&asJSON, "json", false, "print JSON instead of a table")
+	list.Flags().StringVar(&listDB, "db", "", "read this audit database file directly instead of asking a running gateway")
+	cmd.AddCommand(list)
+
+	var before string
+	prune := &cobra.Command{
+		Use:          "prune",
+		Short:        "Delete recorded operations older than a time",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newAdminClient(*cfgPath, flags)
+			if err != nil {
+				return err
+			}
+			var resp struct {
+				Deleted int64 `json:"deleted"`
+			}
+			if err := c.do(http.MethodDelete, "/audit/calls?"+url.Values{"before": {before}}.Encode(), nil, &resp); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted %d records.\n", resp.Deleted)
+			return nil
+		},
+	}
+	prune.Flags().StringVar(&before, "before", "", "delete records before this time (RFC 3339, or a duration such as 720h)")
+	_ = prune.MarkFlagRequired("before")
+	cmd.AddCommand(prune)
+
+	var checkpointFile, publicKey, dbPath string
+	verify := &cobra.Command{
+		Use:          "verify",
+		Short:        "Check the audit log's hash chain for edited or deleted records",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var cp *store.SignedCheckpoint
+			if checkpointFile != "" {
+				var err error
+				if cp, err = readCheckpoint(checkpointFile, publicKey, *cfgPath); err != nil {
+					return err
+				}
+			}
+			var resp struct {
+				Chain      store.ChainStatus `json:"chain"`
+				Checkpoint string            `json:"checkpoint"`
+			}
+			if dbPath != "" {
+				if err := store.OpenReadOnly(dbPath); err != nil {
+					return err
+				}
+				defer store.Close()
+				var err error
+				if resp.Chain, err = store.VerifyChain(); err != nil {
+					return err
+				}
+				if cp != nil && resp.Chain.Broken == nil {
+					if resp.Checkpoint, err = store.CompareCheckpoint(resp.Chain, cp.Checkpoint.LastID, cp.Checkpoint.LastHash); err != nil {
+						return err
+					}
+				}
+			} else {
+				c, err := newAdminClient(*cfgPath, flags)
+				if err != nil {
+					return err
+				}
+				path := "/audit/verify"
+				if cp != nil {
+					path += "?" + url.Values{
+						"id":   {strconv.FormatInt(cp.Checkpoint.LastID, 10)},
+						"hash": {cp.Checkpoint.LastHash},
+					}.Encode()
+				}
+				if err := c.do(http.MethodGet, path, nil, &resp); err != nil {
+					return err
+				}
+			}
+
+			st := resp.Chain
+			if st.Broken != nil {
+				fmt.Printf("Chain broken at record %d: %s.\n", st.Broken.ID, st.Broken.Reason)
+				if st.Records > 0 {
+					fmt.Printf("Records %d to %d before it are intact.\n", st.FirstID, st.LastID)
+				}
+				return fmt.Errorf("audit log failed verification")
+			}
+			if st.Records == 0 {
+				fmt.Println("The audit log is empty.")
+			} else {
+				fmt.Printf("Chain intact: %d records, %d to %d, head %s.\n", st.Records, st.FirstID, st.LastID, st.LastHash)
+				if st.Anchor != "" {
+					fmt.Printf("Records before %d were pruned; the chain is anchored at %s.\n", st.FirstID, st.Anchor)
+				}
+			}
+			if cp == nil {
+				return nil
+			}
+			switch resp.Checkpoint {
+			case store.CheckpointMatch:
+				fmt.Printf("Checkpoint of %s at record %d matches.\n", cp.Checkpoint.CreatedAt.Local().Format("2006-01-02 15:04:05"), cp.Checkpoint.LastID)
+			case store.CheckpointPruned:
+				fmt.Printf("Checkpoint record %d has been pruned and can no longer be checked.\n", cp.Checkpoint.LastID)
+			case store.CheckpointMissing:
+				return fmt.Errorf("checkpoint record %d is missing: records were deleted from the end of the log", cp.Checkpoint.LastID)
+			default:
+				return fmt.Errorf("checkpoint record %d does not match: the log was rewritten up to it", cp.Checkpoint.LastID)
+			}
+			return nil
+		},
+	}
+	verify.Flags().StringVar(&checkpointFile, "checkpoint", "", "also check the log against a checkpoint saved by `gomcp audit checkpoint`")
+	verify.Flags().StringVar(&publicKey, "public-key", "", "PEM Ed25519 public key the checkpoint must be signed with (default: from store.checkpoint_key in config)")
+	verify.Flags().StringVar(&dbPath, "db", "", "check this audit database file directly instead of asking a running gateway")
+	cmd.AddCommand(verify)
+
+	var out, checkpointDB, signingKey string
+	checkpoint := &cobra.Command{
+		Use:          "checkpoint",
+		Short:        "Save a signed checkpoint of the audit log's hash chain",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var cp store.SignedCheckpoint
+			if checkpointDB != "" {
+				var err error
+				if cp, err = signOffline(checkpointDB, signingKey, *cfgPath); err != nil {
+					return err
+				}
+			} else {
+				c, err := newAdminClient(*cfgPath, flags)
+				if err != nil {
+					return err
+				}
+				if err := c.do(http.MethodGet, "/audit/checkpoint", nil, &cp); err != nil {
+					return err
+				}
+			}
+			b, err := json.MarshalIndent(cp, "", "  ")
+			if err != nil {
+				return err
+			}
+			b = append(b, '\n')
+			if out == "" {
+				_, err = os.Stdout.Write(b)
+				return err
+			}
+			if err := os.WriteFile(out, b, 0644); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Checkpoint at record %d written to %s.\n", cp.Checkpoint.LastID, out)
+			return nil
+		},
+	}
+	checkpoint.Flags().StringVarP(&out, "output", "o", "", "write the checkpoint to this file instead of stdout")
+	checkpoint.Flags().StringVar(&checkpointDB, "db", "", "checkpoint this audit database file directly instead of asking a running gateway")
+	checkpoint.Flags().StringVar(&signingKey, "signing-key", "", "PEM Ed25519 private key to sign with when using --db (default: store.checkpoint_key in config)")
+	cmd.AddCommand(checkpoint)
+	return cmd
+}
+
+// queryOffline returns the pages of audit list for a database opened with
+// store.OpenReadOnly, checking the flags as the gateway checks its query
+// parameters.
+func queryOffline(filter store.CallFilter, since, until string) (func(cursor string) (auditPage, error), error) {
+	switch filter.Status {
+	case "", store.StatusSuccess, store.StatusError, store.StatusCancelled, store.StatusTimeout:
+	default:
+		return nil, fmt.Errorf("--status must be success, error, cancelled or timeout")
+	}
+	var err error
+	now := time.Now()
+	if filter.Since, err = store.ParseTime(since, now); err != nil {
+		return nil, fmt.Errorf("--since: %w", err)
+	}
+	if filter.Until, err = store.ParseTime(until, now); err != nil {
+		return nil, fmt.Errorf("--until: %w", err)
+	}
+	if filter.Limit < 0 || filter.Limit > store.MaxQueryLimit {
+		return nil, fmt.Errorf("--limit must be between 1 and %d", store.MaxQueryLimit)
+	}
+	return func(cursor string) (auditPage, error) {
+		f := filter
+		if cursor != "" {
+			id, err := strconv.ParseInt(cursor, 10, 64)
+			if err != nil || id <= 0 {
+				return auditPage{}, fmt.Errorf("invalid cursor %q", cursor)
+			}
+			f.Cursor = id
+		}
+		calls, next, err := store.QueryCalls(f)
+		if err != nil {
+			return auditPage{}, err
+		}
+		page := auditPage{Calls: calls}
+		if next > 0 {
+			page.NextCursor = strconv.FormatInt(next, 10)
+		}
+		return page, nil
+	}, nil
+}
+
+// signOffline signs a checkpoint of the audit database at dbPath with keyPath,
+// or store.checkpoint_key in the config.
+func signOffline(dbPath, keyPath, cfgPath string) (store.SignedCheckpoint, error) {
+	if keyPath == "" {
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return store.SignedCheckpoint{}, err
+		}
+		if cfg.Store.CheckpointKey == "" {
+			return store.SignedCheckpoint{}, fmt.Errorf("--signing-key is required when the config has no store.checkpoint_key")
+		}
+		keyPath = cfg.Store.CheckpointKey
+	}
+	key, err := store.LoadSigningKey(keyPath)
+	if err != nil {
+		return store.SignedCheckpoint{}, err
+	}
+	if err := store.OpenReadOnly(dbPath); err != nil {
+		return store.SignedCheckpoint{}, err
+	}
+	defer store.Close()
+	return store.NewCheckpoint(key)
+}
+
+// readCheckpoint loads a saved checkpoint and checks its signature against
+// publicKey, or the public half of store.checkpoint_key in the config.
+func readCheckpoint(path, publicKey, cfgPath string) (*store.SignedCheckpoint, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cp store.SignedCheckpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if publicKey == "" {
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Store.CheckpointKey == "" {
+			return nil, fmt.Errorf("--public-key is required when the config has no store.checkpoint_key")
+		}
+		publicKey = cfg.Store.CheckpointKey
+	}
+	pub, err := store.LoadPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !cp.Verify(pub) {
+		return nil, fmt.Errorf("%s: signature does not verify with %s", path, publicKey)
+	}
+	return &cp, nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// truncate shortens s to at most n runes for table output.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -25,6 +25,9 @@ func main() {
 	root.AddCommand(startCmd(&cfgPath))
 	root.AddCommand(serveCmd(&cfgPath))
 	root.AddCommand(mcpCmd(&cfgPath))
+	root.AddCommand(approvalsCmd(&cfgPath))
+	root.AddCommand(auditCmd(&cfgPath))
+	root.AddCommand(tokenCmd())
 
 	if err := root.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"gomcp-pilot/internal/auth"
+)
+
+// tokenSecretBytes is the entropy of generated secrets.
+const tokenSecretBytes = 32
+
+func tokenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Create API token secrets and their hashes for the tokens config",
+	}
+
+	var scopes []string
+	newCmd := &cobra.Command{
+		Use:          "new <name>",
+		Short:        "Generate a secret and print the config entry for it",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b := make([]byte, tokenSecretBytes)
+			if _, err := rand.Read(b); err != nil {
+				return err
+			}
+			secret := "gmp_" + base64.RawURLEncoding.EncodeToString(b)
+
+			fmt.Fprintf(os.Stderr, "Secret (shown once, give it to the client): %s\n\n", secret)
+			fmt.Printf("  - name: %q\n", args[0])
+			fmt.Printf("    secret_hash: %q\n", auth.HashSecret(secret))
+			fmt.Printf("    scopes: [%s]\n", strings.Join(scopes, ", "))
+			return nil
+		},
+	}
+	newCmd.Flags().StringSliceVar(&scopes, "scopes", []string{"list", "call"}, "scopes to grant: list, call, read, admin")
+	cmd.AddCommand(newCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "hash [secret]",
+		Short:        "Print the secret_hash for an existing secret (read from stdin if omitted)",
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var secret string
+			if len(args) == 1 {
+				secret = args[0]
+			} else {
+				line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil && line == "" {
+					return fmt.Errorf("read secret: %w", err)
+				}
+				secret = strings.TrimRight(line, "\r\n")
+			}
+			if secret == "" {
+				return fmt.Errorf("empty secret")
+			}
+			fmt.Println(auth.HashSecret(secret))
+			return nil
+		},
+	})
+	return cmd
+}
@@ -27,10 +27,11 @@ func RunTUI(ctx context.Context, cfg *config.Config) error {
 	if err := logger.InitLogger(); err != nil {
 		return err
 	}
-	if err := store.InitStore(); err != nil {
+	if err := store.InitStore(cfg.Store.Path); err != nil {
 		return err
 	}
 	defer store.Close()
+	go runRetention(ctx, cfg.Store)
 
 	// Redirect standard logger to TUI
 	log.SetOutput(&logWriter{})
@@ -38,29 +39,9 @@ func RunTUI(ctx context.Context, cfg *config.Config) error {
 
 	// 2. Initialize Process Manager with TUI Interceptor
 	manager := process.NewManager()
-	manager.SetInterceptor(func(upstream, tool, args string) bool {
-		// Send request to TUI
-		respChan := make(chan bool)
-		tui.InterceptChan <- tui.InterceptRequest{
-			Upstream:     upstream,
-			Tool:         tool,
-			Args:         args,
-			ResponseChan: respChan,
-		}
-		// Block waiting for user decision
-		logger.Global.Info("Waiting for user approval",
-			zap.String("upstream", upstream),
-			zap.String("tool", tool))
-
-		allowed := <-respChan
-
-		if allowed {
-			logger.Global.Info("Request approved", zap.String("tool", tool))
-		} else {
-			logger.Global.Warn("Request denied", zap.String("tool", tool))
-		}
-		return allowed
-	})
+	// Approvals are shared between the TUI modal and the HTTP API; the first answer wins.
+	approvals := newApprovalQueue(cfg, manager)
+	manager.SetInterceptor(queueInterceptor(approvals, stdLogger))
 
 	if err := manager.StartAll(ctx, cfg); err != nil {
 		return err
@@ -73,6 +54,8 @@ func RunTUI(ctx context.Context, cfg *config.Config) error {
 		return err
 	}
 	srv := server.New(cfg, manager, stdLogger, mcpSrv)
+	srv.SetApprovals(approvals)
+	go watchConfig(ctx, cfg, manager, mcpSrv, srv)
 	go func() {
 		if err := srv.Start(ctx); err != nil {
 			logger.Global.Error("HTTP server failed to start", zap.String("error", err.Error()))
@@ -98,8 +81,23 @@ func RunTUI(ctx context.Context, cfg *config.Config) error {
 		return infos, nil
 	}
 
+	// Health Fetcher closure
+	healthFetcher := func() map[string]tui.HealthInfo {
+		states := manager.UpstreamStates()
+		health := make(map[string]tui.HealthInfo, len(states))
+		for name, st := range states {
+			health[name] = tui.HealthInfo{
+				Status:    string(st.State),
+				Restarts:  st.Restarts,
+				LastError: st.LastError,
+				Config:    st.Config,
+			}
+		}
+		return health
+	}
+
 	// 4. Start TUI (Blocks until quit)
-	p := tea.NewProgram(tui.InitialModel(cfg, toolFetcher), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	p := tea.NewProgram(tui.InitialModel(cfg, toolFetcher, healthFetcher, approvals), tea.WithAltScreen(), tea.WithMouseCellMotion())
 	if _, err := p.Run(); err != nil {
 		return err
 	}
@@ -113,22 +111,19 @@ func RunHeadless(ctx context.Context, cfg *config.Config) error {
 	if err := logger.InitLogger(); err != nil {
 		return err
 	}
-	if err := store.InitStore(); err != nil {
+	if err := store.InitStore(cfg.Store.Path); err != nil {
 		return err
 	}
 	defer store.Close()
+	go runRetention(ctx, cfg.Store)
 
 	// Standard logger
 	stdLogger := log.New(os.Stdout, "[gomcp] ", log.LstdFlags)
 
-	// 2. Initialize Process Manager with simple logging interceptor
+	// 2. Initialize Process Manager; approval_mode decides calls that need approval
 	manager := process.NewManager()
-	manager.SetInterceptor(func(upstream, tool, args string) bool {
-		logger.Global.Info("Auto-approving tool call (Headless mode)",
-			zap.String("upstream", upstream),
-			zap.String("tool", tool))
-		return true // Auto-approve in headless mode for now
-	})
+	approvals := newApprovalQueue(cfg, manager)
+	manager.SetInterceptor(headlessInterceptor(cfg.ApprovalMode, approvals, stdLogger))
 
 	if err := manager.StartAll(ctx, cfg); err != nil {
 		return err
@@ -141,6 +136,8 @@ func RunHeadless(ctx context.Context, cfg *config.Config) error {
 		return err
 	}
 	srv := server.New(cfg, manager, stdLogger, mcpSrv)
+	srv.SetApprovals(approvals)
+	go watchConfig(ctx, cfg, manager, mcpSrv, srv)
 
 	// Run server in foreground (blocking) since we don't have TUI to block
 	logger.Global.Info("Running in Headless Mode. Press Ctrl+C to stop.")
@@ -157,11 +154,11 @@ func (w *logWriter) Write(p []byte) (n int, err error) {
 		msg = msg[:len(msg)-1]
 	}
 
-	logger.LogChan <- logger.LogEntry{
+	logger.Send(logger.LogEntry{
 		Level:     "INFO",
 		Message:   msg,
 		Timestamp: time.Now(),
-	}
+	})
 	return len(p), nil
 }
 
@@ -171,10 +168,17 @@ func RunMCP(ctx context.Context, cfg *config.Config) error {
 	if err := logger.InitLogger(); err != nil {
 		return err
 	}
+	if err := store.InitStore(cfg.Store.Path); err != nil {
+		return err
+	}
+	defer store.Close()
+	go runRetention(ctx, cfg.Store)
 
 	stdLog := log.New(os.Stderr, "[gomcp-stdio] ", log.LstdFlags|log.Lmicroseconds)
 
 	manager := process.NewManager()
+	approvals := newApprovalQueue(cfg, manager)
+	manager.SetInterceptor(headlessInterceptor(cfg.ApprovalMode, approvals, stdLog))
 	if err := manager.StartAll(ctx, cfg); err != nil {
 		return err
 	}
@@ -184,8 +188,21 @@ func RunMCP(ctx context.Context, cfg *config.Config) error {
 	if err != nil {
 		return err
 	}
+
+	// stdio carries MCP traffic, so queued approvals are resolved over a small admin listener.
+	var admin *server.Server
+	if cfg.ApprovalMode == config.ApprovalQueue {
+		admin = server.New(cfg, manager, stdLog, nil)
+		admin.SetApprovals(approvals)
+		go func() {
+			if err := admin.StartAdmin(ctx); err != nil {
+				stdLog.Printf("approval admin API failed: %v", err)
+			}
+		}()
+	}
+	go watchConfig(ctx, cfg, manager, srv, admin)
 	stdLog.Println("stdio MCP server ready (connect with MCP-compatible client)")
-	return mcpbridge.ServeStdio(ctx, srv)
+	return mcpbridge.ServeStdio(ctx, srv, manager)
 }
 
 // WithSignals wraps a context with SIGINT/SIGTERM cancellation.
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"log"
+
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/approval"
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/logger"
+	"gomcp-pilot/internal/process"
+)
+
+// newApprovalQueue builds the queue shared by the TUI, the HTTP API and the CLI.
+// Edited arguments are checked against the tool's inputSchema as the
+// upstream's schema_validation mode says.
+func newApprovalQueue(cfg *config.Config, manager *process.Manager) *approval.Queue {
+	q := approval.NewQueue(cfg.ApprovalTimeout, cfg.ApprovalTimeoutAction == config.ActionAllow)
+	q.SetValidator(manager.ValidateEdited)
+	return q
+}
+
+// Approvers recorded for calls decided by approval_mode instead of a person.
+const (
+	approverModeAllow = "approval_mode:allow"
+	approverModeDeny  = "approval_mode:deny"
+)
+
+// headlessInterceptor decides calls that need approval when no TUI is attached,
+// according to approval_mode.
+func headlessInterceptor(mode string, q *approval.Queue, stdLog *log.Logger) process.Interceptor {
+	queued := queueInterceptor(q, stdLog)
+	return func(ctx context.Context, upstream, tool, args string) process.Verdict {
+		callLog := logger.Global.With(zap.String("request_id", process.RequestIDFromContext(ctx)))
+		switch mode {
+		case config.ApprovalAllow:
+			callLog.Info("Auto-approving tool call (approval_mode=allow)",
+				zap.String("upstream", upstream),
+				zap.String("tool", tool))
+			return process.Verdict{Approved: true, Approver: approverModeAllow}
+		case config.ApprovalQueue:
+			return queued(ctx, upstream, tool, args)
+		default:
+			callLog.Warn("Denying tool call that needs approval (approval_mode=deny)",
+				zap.String("upstream", upstream),
+				zap.String("tool", tool))
+			return process.Verdict{Approver: approverModeDeny}
+		}
+	}
+}
+
+// queueInterceptor parks calls in q until they are answered (from the TUI, the
+// HTTP API or `gomcp approvals`), they expire, or the caller gives up.
+func queueInterceptor(q *approval.Queue, stdLog *log.Logger) process.Interceptor {
+	return func(ctx context.Context, upstream, tool, args string) process.Verdict {
+		callLog := logger.Global.With(zap.String("request_id", process.RequestIDFromContext(ctx)))
+		p := q.Submit(upstream, tool, args, process.GrantsAllowed(ctx))
+		callLog.Info("Tool call queued for approval",
+			zap.String("id", p.ID),
+			zap.String("upstream", upstream),
+			zap.String("tool", tool))
+		stdLog.Printf("Approval %s pending: %s/%s %s (gomcp approvals approve|deny %s)", p.ID, upstream, tool, args, p.ID)
+
+		d := p.Wait(ctx)
+		switch {
+		case ctx.Err() != nil && !d.Approved:
+			callLog.Warn("Approval request withdrawn, caller went away", zap.String("id", p.ID), zap.String("tool", tool))
+		case d.TimedOut:
+			callLog.Warn("Approval timed out, applied default decision",
+				zap.String("id", p.ID),
+				zap.String("tool", tool),
+				zap.Bool("approved", d.Approved))
+		case d.Approved:
+			callLog.Info("Request approved", zap.String("id", p.ID), zap.String("tool", tool), zap.String("approver", d.Approver))
+		default:
+			callLog.Warn("Request denied", zap.String("id", p.ID), zap.String("tool", tool), zap.String("approver", d.Approver))
+		}
+		return process.Verdict{Approved: d.Approved, Arguments: d.Arguments, Approver: d.Approver}
+	}
+}
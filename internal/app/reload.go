@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/logger"
+	"gomcp-pilot/internal/mcpbridge"
+	"gomcp-pilot/internal/process"
+	"gomcp-pilot/internal/server"
+)
+
+// configPollInterval is how often the config file's modification time is checked.
+const configPollInterval = 2 * time.Second
+
+// watchConfig reloads the config file when it changes on disk or the process
+// receives SIGHUP, then reconciles upstreams, the bridged MCP registrations and
+// the API tokens of api, if any. It blocks until ctx is cancelled.
+func watchConfig(ctx context.Context, cfg *config.Config, manager *process.Manager, mcpSrv *mcpserver.MCPServer, api *server.Server) {
+	if cfg.Path == "" {
+		return
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	lastMod := modTime(cfg.Path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logger.Global.Info("SIGHUP received, reloading config", zap.String("path", cfg.Path))
+		case <-ticker.C:
+			mod := modTime(cfg.Path)
+			if mod.IsZero() || mod.Equal(lastMod) {
+				continue
+			}
+			logger.Global.Info("Config file changed, reloading", zap.String("path", cfg.Path))
+		}
+		lastMod = modTime(cfg.Path)
+
+		next, err := config.Load(cfg.Path)
+		if err != nil {
+			logger.Global.Error("Config reload failed, keeping current upstreams", zap.Error(err))
+			continue
+		}
+		if err := manager.Reload(ctx, next); err != nil {
+			logger.Global.Warn("Some upstreams failed to start after reload", zap.Error(err))
+		}
+		if mcpSrv != nil {
+			if err := mcpbridge.Sync(mcpSrv, manager); err != nil {
+				logger.Global.Error("Failed to refresh MCP registrations", zap.Error(err))
+			}
+		}
+		if api != nil {
+			api.ReloadTokens(next)
+		}
+	}
+}
+
+func modTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/logger"
+	"gomcp-pilot/internal/store"
+)
+
+// pruneInterval is how often audit records past retention_days or max_rows
+// are deleted.
+const pruneInterval = time.Hour
+
+// runRetention prunes and compacts the store as configured until ctx ends.
+func runRetention(ctx context.Context, cfg config.Store) {
+	if cfg.RetentionDays == 0 && cfg.MaxRows == 0 && cfg.VacuumInterval == 0 {
+		return
+	}
+	prune := func() {
+		if cfg.RetentionDays == 0 && cfg.MaxRows == 0 {
+			return
+		}
+		var before time.Time
+		if cfg.RetentionDays > 0 {
+			before = time.Now().AddDate(0, 0, -cfg.RetentionDays)
+		}
+		n, err := store.Prune(before, cfg.MaxRows, "")
+		if err != nil {
+			logger.Global.Warn("Failed to prune audit log", zap.Error(err))
+			return
+		}
+		if n > 0 {
+			logger.Global.Info("Pruned audit log", zap.Int64("deleted", n))
+		}
+	}
+	prune()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	var vacuum <-chan time.Time
+	if cfg.VacuumInterval > 0 && cfg.Path != config.MemoryStore {
+		t := time.NewTicker(cfg.VacuumInterval)
+		defer t.Stop()
+		vacuum = t.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prune()
+		case <-vacuum:
+			start := time.Now()
+			if err := store.Vacuum(); err != nil {
+				logger.Global.Warn("Failed to vacuum audit database", zap.Error(err))
+				continue
+			}
+			logger.Global.Info("Vacuumed audit database", zap.Duration("duration", time.Since(start)))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package approval
+
+import (
+	"fmt"
+	"time"
+
+	"gomcp-pilot/internal/store"
+)
+
+// Grant scopes offered alongside a one-off approval.
+const (
+	GrantTool = "tool" // approve this tool from now on
+	GrantHour = "hour" // approve this tool for GrantHourDuration
+	GrantArgs = "args" // approve calls to this tool with identical arguments
+)
+
+// GrantHourDuration is how long a GrantHour grant lasts.
+const GrantHourDuration = time.Hour
+
+// ApproveWithGrant approves request id and persists a grant of the given
+// scope, so later matching calls skip approval. Other pending requests the new
+// grant covers are approved as well, with "grant:<id>" as their approver, if
+// grants may answer them. The grant is withdrawn if id can no longer be
+// approved.
+func (q *Queue) ApproveWithGrant(id, scope, approver string) error {
+	q.mu.Lock()
+	e, ok := q.pending[id]
+	q.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+
+	g := store.Grant{Upstream: e.req.Upstream, Tool: e.req.Tool}
+	switch scope {
+	case GrantTool:
+	case GrantHour:
+		expires := time.Now().Add(GrantHourDuration)
+		g.ExpiresAt = &expires
+	case GrantArgs:
+		g.Arguments = e.req.Arguments
+	default:
+		return fmt.Errorf("unknown grant scope %q", scope)
+	}
+	grantID, err := store.AddGrant(g)
+	if err != nil {
+		return fmt.Errorf("save grant: %w", err)
+	}
+
+	if err := q.Resolve(id, true, approver); err != nil {
+		// Decided or withdrawn meanwhile: nobody approved the grant.
+		if _, rerr := store.RevokeGrant(grantID); rerr != nil {
+			return fmt.Errorf("%w; revoke grant %d: %w", err, grantID, rerr)
+		}
+		return err
+	}
+	for _, req := range q.List() {
+		if req.Grants && req.Upstream == g.Upstream && req.Tool == g.Tool && (g.Arguments == "" || req.Arguments == g.Arguments) {
+			_ = q.Resolve(req.ID, true, fmt.Sprintf("grant:%d", grantID))
+		}
+	}
+	return nil
+}
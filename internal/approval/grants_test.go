@@ -0,0 +1,104 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"gomcp-pilot/internal/store"
+)
+
+// openStore gives the test a fresh in-memory store.
+func openStore(t *testing.T) {
+	t.Helper()
+	if err := store.InitStore(store.Memory); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		store.Close()
+		store.DB = nil
+	})
+}
+
+func TestApproveWithGrant(t *testing.T) {
+	openStore(t)
+
+	q := NewQueue(0, false)
+	first := q.Submit("fs", "write", `{"path":"a"}`, true)
+	same := q.Submit("fs", "write", `{"path":"a"}`, true)
+	other := q.Submit("fs", "write", `{"path":"b"}`, true)
+	strict := q.Submit("fs", "write", `{"path":"a"}`, false)
+	firstDone, sameDone := waitAsync(context.Background(), first), waitAsync(context.Background(), same)
+
+	if err := q.ApproveWithGrant(first.ID, "forever", "tui"); err == nil || !strings.Contains(err.Error(), "unknown grant scope") {
+		t.Errorf("unknown scope: err = %v", err)
+	}
+	if err := q.ApproveWithGrant(first.ID, GrantArgs, "tui"); err != nil {
+		t.Fatal(err)
+	}
+	if d := receive(t, firstDone); !d.Approved || d.Approver != "tui" {
+		t.Errorf("granted request: decision = %+v, want approved by tui", d)
+	}
+	if d := receive(t, sameDone); !d.Approved || !strings.HasPrefix(d.Approver, "grant:") {
+		t.Errorf("covered request: decision = %+v, want approved by the grant", d)
+	}
+	if got := q.List(); len(got) != 2 || got[0].ID != other.ID || got[1].ID != strict.ID {
+		t.Errorf("List = %+v, want the request with other arguments and the one grants may not answer", got)
+	}
+	if id, err := store.MatchGrant("fs", "write", `{"path":"a"}`); err != nil || id == 0 {
+		t.Errorf("MatchGrant = %d, %v; want the saved grant", id, err)
+	}
+}
+
+func TestApproveWithGrantWithoutStore(t *testing.T) {
+	q := NewQueue(0, false)
+	p := q.Submit("fs", "write", `{}`, true)
+	if err := q.ApproveWithGrant(p.ID, GrantTool, "tui"); !errors.Is(err, store.ErrNoStore) {
+		t.Errorf("err = %v, want ErrNoStore", err)
+	}
+	if got := q.List(); len(got) != 1 {
+		t.Errorf("request was resolved although its grant was not saved")
+	}
+}
+
+func TestApproveWithGrantRacingResolve(t *testing.T) {
+	openStore(t)
+	q := NewQueue(0, false)
+	for i := range 50 {
+		p := q.Submit("fs", "write", `{}`, true)
+		done := waitAsync(context.Background(), p)
+		var (
+			wg       sync.WaitGroup
+			grantErr error
+		)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			grantErr = q.ApproveWithGrant(p.ID, GrantTool, "tui")
+		}()
+		go func() {
+			defer wg.Done()
+			_ = q.Resolve(p.ID, false, "api")
+		}()
+		wg.Wait()
+		d := receive(t, done)
+
+		grants, err := store.ListGrants()
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case grantErr == nil && (!d.Approved || len(grants) != 1):
+			t.Fatalf("iteration %d: grant approved, but decision %+v and %d grants", i, d, len(grants))
+		case grantErr != nil && (!errors.Is(grantErr, ErrNotFound) || d.Approved || len(grants) != 0):
+			t.Fatalf("iteration %d: err %v, decision %+v and %d grants; want the grant withdrawn", i, grantErr, d, len(grants))
+		}
+		for _, g := range grants {
+			if _, err := store.RevokeGrant(g.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
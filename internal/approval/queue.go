@@ -0,0 +1,284 @@
+// Package approval holds tool calls that are waiting for a human decision so
+// they can be resolved from outside the process, e.g. over the HTTP admin API.
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when resolving a request that does not exist or was
+// already decided.
+var ErrNotFound = errors.New("approval request not found")
+
+// Request is a tool call waiting for approval.
+type Request struct {
+	ID        string    `json:"id"`
+	Upstream  string    `json:"upstream"`
+	Tool      string    `json:"tool"`
+	Arguments string    `json:"arguments"`
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is when the queue's default decision applies; nil if requests never expire.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Grants is set when a standing grant may answer the call: it matched no
+	// policy rule, or an ask rule with grants: true. Only such requests are
+	// approved by a grant made for another request.
+	Grants bool `json:"grants"`
+}
+
+// Decision is the outcome of waiting on a request.
+type Decision struct {
+	Approved bool
+	// TimedOut is set when nobody answered before ExpiresAt; Approved then
+	// holds the queue's default decision.
+	TimedOut bool
+	// Arguments holds the JSON arguments the reviewer approved when they
+	// edited them, and is empty otherwise.
+	Arguments string
+	// Approver names who decided: whatever the resolver passed (e.g. "tui" or
+	// "api:<token>"), "grant:<id>" for requests a new grant covered, or
+	// "timeout". It is empty when the request was withdrawn.
+	Approver string
+}
+
+// ApproverTimeout is the Approver of requests nobody answered in time.
+const ApproverTimeout = "timeout"
+
+// Event types published to subscribers.
+const (
+	EventPending   = "pending"
+	EventApproved  = "approved"
+	EventDenied    = "denied"
+	EventWithdrawn = "withdrawn" // the caller gave up before a decision
+	EventExpired   = "expired"   // nobody answered in time; the default decision applied
+)
+
+// Event reports a change to the queue.
+type Event struct {
+	Type    string  `json:"type"`
+	Request Request `json:"request"`
+}
+
+// subscriberBuffer bounds how far a subscriber may fall behind before events
+// are dropped for it; subscribers can always re-read List.
+const subscriberBuffer = 64
+
+type entry struct {
+	seq      int64
+	req      Request
+	decision chan Decision
+}
+
+// Queue tracks pending requests. The first decision for a request wins.
+type Queue struct {
+	mu      sync.Mutex
+	seq     int64
+	pending map[string]*entry
+	subs    map[chan Event]struct{}
+
+	timeout        time.Duration
+	timeoutApprove bool
+	validate       Validator
+}
+
+// Validator checks edited arguments for a tool before they are approved.
+type Validator func(upstream, tool, args string) error
+
+// SetValidator installs the check ApproveEdited runs on edited arguments.
+func (q *Queue) SetValidator(fn Validator) {
+	q.mu.Lock()
+	q.validate = fn
+	q.mu.Unlock()
+}
+
+// NewQueue returns an empty queue. Requests not answered within timeout are
+// decided by timeoutApprove; a zero timeout waits indefinitely.
+func NewQueue(timeout time.Duration, timeoutApprove bool) *Queue {
+	return &Queue{
+		pending:        make(map[string]*entry),
+		subs:           make(map[chan Event]struct{}),
+		timeout:        timeout,
+		timeoutApprove: timeoutApprove,
+	}
+}
+
+// Subscribe returns a channel receiving every queue event from now on, and a
+// func that unsubscribes and closes it.
+func (q *Queue) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	q.mu.Lock()
+	q.subs[ch] = struct{}{}
+	q.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			q.mu.Lock()
+			delete(q.subs, ch)
+			q.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publish must be called with q.mu held.
+func (q *Queue) publish(typ string, req Request) {
+	ev := Event{Type: typ, Request: req}
+	for ch := range q.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Pending is a submitted request awaiting its decision.
+type Pending struct {
+	Request
+	q *Queue
+	e *entry
+}
+
+// Submit enqueues a request; grants says whether standing grants may answer
+// it. The caller must call Wait on the result.
+func (q *Queue) Submit(upstream, tool, args string, grants bool) *Pending {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	now := time.Now()
+	e := &entry{
+		seq: q.seq,
+		req: Request{
+			ID:        strconv.FormatInt(q.seq, 10),
+			Upstream:  upstream,
+			Tool:      tool,
+			Arguments: args,
+			CreatedAt: now,
+			Grants:    grants,
+		},
+		decision: make(chan Decision, 1),
+	}
+	if q.timeout > 0 {
+		expires := now.Add(q.timeout)
+		e.req.ExpiresAt = &expires
+	}
+	q.pending[e.req.ID] = e
+	q.publish(EventPending, e.req)
+	return &Pending{Request: e.req, q: q, e: e}
+}
+
+// Wait blocks until the request is resolved, expires, or ctx is done, in which
+// case it is withdrawn from the queue and treated as denied.
+func (p *Pending) Wait(ctx context.Context) Decision {
+	var expired <-chan time.Time
+	if p.ExpiresAt != nil {
+		t := time.NewTimer(time.Until(*p.ExpiresAt))
+		defer t.Stop()
+		expired = t.C
+	}
+
+	select {
+	case d := <-p.e.decision:
+		return d
+	case <-expired:
+		if p.q.remove(p.ID, EventExpired) {
+			return Decision{Approved: p.q.timeoutApprove, TimedOut: true, Approver: ApproverTimeout}
+		}
+	case <-ctx.Done():
+		if p.q.remove(p.ID, EventWithdrawn) {
+			return Decision{}
+		}
+	}
+	// Resolved concurrently with the timeout or cancellation; honor the answer.
+	return <-p.e.decision
+}
+
+// remove drops a still-pending request and publishes typ. It reports false if
+// the request was already resolved.
+func (q *Queue) remove(id, typ string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.pending[id]
+	if !ok {
+		return false
+	}
+	delete(q.pending, id)
+	q.publish(typ, e.req)
+	return true
+}
+
+// List returns the pending requests, oldest first.
+func (q *Queue) List() []Request {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries := make([]*entry, 0, len(q.pending))
+	for _, e := range q.pending {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+
+	reqs := make([]Request, len(entries))
+	for i, e := range entries {
+		reqs[i] = e.req
+	}
+	return reqs
+}
+
+// Resolve approves or denies a pending request on behalf of approver.
+func (q *Queue) Resolve(id string, approve bool, approver string) error {
+	return q.decide(id, Decision{Approved: approve, Approver: approver})
+}
+
+// ApproveEdited approves a pending request with arguments the reviewer edited.
+// args must be a JSON object and pass the queue's Validator, if any.
+func (q *Queue) ApproveEdited(id, args, approver string) error {
+	q.mu.Lock()
+	e, ok := q.pending[id]
+	validate := q.validate
+	q.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(args), &obj); err != nil {
+		return fmt.Errorf("arguments must be a JSON object: %w", err)
+	}
+	compact, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	if validate != nil {
+		if err := validate(e.req.Upstream, e.req.Tool, string(compact)); err != nil {
+			return err
+		}
+	}
+	return q.decide(id, Decision{Approved: true, Arguments: string(compact), Approver: approver})
+}
+
+func (q *Queue) decide(id string, d Decision) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.pending[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(q.pending, id)
+	e.decision <- d
+	if d.Approved {
+		q.publish(EventApproved, e.req)
+	} else {
+		q.publish(EventDenied, e.req)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitAsync runs p.Wait in the background and returns its decision on a channel.
+func waitAsync(ctx context.Context, p *Pending) <-chan Decision {
+	ch := make(chan Decision, 1)
+	go func() { ch <- p.Wait(ctx) }()
+	return ch
+}
+
+func receive(t *testing.T, ch <-chan Decision) Decision {
+	t.Helper()
+	select {
+	case d := <-ch:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not return")
+		return Decision{}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	q := NewQueue(0, false)
+	a := q.Submit("fs", "write", `{"path":"a"}`, true)
+	b := q.Submit("fs", "delete", `{}`, true)
+	if a.ExpiresAt != nil {
+		t.Error("request expires although the queue has no timeout")
+	}
+	if got := q.List(); len(got) != 2 || got[0].ID != a.ID || got[1].ID != b.ID {
+		t.Fatalf("List = %+v, want a then b", got)
+	}
+
+	done := waitAsync(context.Background(), a)
+	if err := q.Resolve(a.ID, true, "tui"); err != nil {
+		t.Fatal(err)
+	}
+	if d := receive(t, done); !d.Approved || d.Approver != "tui" || d.TimedOut {
+		t.Errorf("decision = %+v, want approved by tui", d)
+	}
+	if err := q.Resolve(a.ID, false, "tui"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("resolving twice: err = %v, want ErrNotFound", err)
+	}
+	if err := q.Resolve("nope", true, "tui"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("resolving unknown id: err = %v, want ErrNotFound", err)
+	}
+	if got := q.List(); len(got) != 1 || got[0].ID != b.ID {
+		t.Errorf("List = %+v, want only b", got)
+	}
+}
+
+func TestCancelWithdrawsRequest(t *testing.T) {
+	q := NewQueue(0, true)
+	events, unsubscribe := q.Subscribe()
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := q.Submit("fs", "write", `{}`, true)
+	done := waitAsync(ctx, p)
+	cancel()
+
+	if d := receive(t, done); d.Approved || d.Approver != "" {
+		t.Errorf("decision after cancel = %+v, want withdrawn and denied", d)
+	}
+	if got := q.List(); len(got) != 0 {
+		t.Errorf("List after cancel = %+v, want empty", got)
+	}
+	if err := q.Resolve(p.ID, true, "tui"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("resolving a withdrawn request: err = %v, want ErrNotFound", err)
+	}
+
+	want := []string{EventPending, EventWithdrawn}
+	for _, typ := range want {
+		select {
+		case ev := <-events:
+			if ev.Type != typ || ev.Request.ID != p.ID {
+				t.Errorf("event = %s %s, want %s %s", ev.Type, ev.Request.ID, typ, p.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %s event", typ)
+		}
+	}
+}
+
+func TestConcurrentResolveFirstAnswerWins(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		q := NewQueue(0, false)
+		p := q.Submit("fs", "write", `{}`, true)
+		done := waitAsync(context.Background(), p)
+
+		resolvers := []struct {
+			approve  bool
+			approver string
+		}{{true, "tui"}, {false, "api:ci"}, {true, "web"}, {false, "api:ops"}}
+		winners := make(chan int, len(resolvers))
+		var wg sync.WaitGroup
+		for n, r := range resolvers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := q.Resolve(p.ID, r.approve, r.approver)
+				switch {
+				case err == nil:
+					winners <- n
+				case !errors.Is(err, ErrNotFound):
+					t.Errorf("%s: %v", r.approver, err)
+				}
+			}()
+		}
+		wg.Wait()
+		close(winners)
+
+		if len(winners) != 1 {
+			t.Fatalf("%d resolvers succeeded, want exactly one", len(winners))
+		}
+		w := resolvers[<-winners]
+		if d := receive(t, done); d.Approved != w.approve || d.Approver != w.approver {
+			t.Fatalf("decision = %+v, want the winner's %+v", d, w)
+		}
+	}
+}
+
+func TestTimeoutAppliesDefault(t *testing.T) {
+	for _, approve := range []bool{false, true} {
+		q := NewQueue(20*time.Millisecond, approve)
+		events, unsubscribe := q.Subscribe()
+		p := q.Submit("fs", "write", `{}`, true)
+		if p.ExpiresAt == nil || p.ExpiresAt.Sub(p.CreatedAt) != 20*time.Millisecond {
+			t.Fatalf("ExpiresAt = %v, want CreatedAt+20ms", p.ExpiresAt)
+		}
+
+		d := receive(t, waitAsync(context.Background(), p))
+		if d.Approved != approve || !d.TimedOut || d.Approver != ApproverTimeout {
+			t.Errorf("default %v: decision = %+v, want timed out with the default", approve, d)
+		}
+		if got := q.List(); len(got) != 0 {
+			t.Errorf("List after timeout = %+v, want empty", got)
+		}
+		if err := q.Resolve(p.ID, !approve, "tui"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("answering an expired request: err = %v, want ErrNotFound", err)
+		}
+		<-events // pending
+		if ev := <-events; ev.Type != EventExpired {
+			t.Errorf("event = %s, want %s", ev.Type, EventExpired)
+		}
+		unsubscribe()
+	}
+}
+
+func TestAnswerBeforeTimeout(t *testing.T) {
+	q := NewQueue(time.Hour, true)
+	p := q.Submit("fs", "write", `{}`, true)
+	done := waitAsync(context.Background(), p)
+	if err := q.Resolve(p.ID, false, "web"); err != nil {
+		t.Fatal(err)
+	}
+	if d := receive(t, done); d.Approved || d.TimedOut || d.Approver != "web" {
+		t.Errorf("decision = %+v, want denied by web", d)
+	}
+}
+
+func TestApproveEdited(t *testing.T) {
+	q := NewQueue(0, false)
+	q.SetValidator(func(upstream, tool, args string) error {
+		if args == `{}` {
+			return errors.New("missing required arguments: path")
+		}
+		return nil
+	})
+	p := q.Submit("fs", "write", `{"path":"a"}`, true)
+	done := waitAsync(context.Background(), p)
+
+	if err := q.ApproveEdited(p.ID, `[1]`, "tui"); err == nil {
+		t.Error("non-object arguments were accepted")
+	}
+	if err := q.ApproveEdited(p.ID, `{}`, "tui"); err == nil {
+		t.Error("arguments failing the validator were accepted")
+	}
+	if err := q.ApproveEdited(p.ID, "{\n  \"path\": \"b\"\n}", "tui"); err != nil {
+		t.Fatal(err)
+	}
+	if d := receive(t, done); !d.Approved || d.Arguments != `{"path":"b"}` {
+		t.Errorf("decision = %+v, want approved with compacted edited arguments", d)
+	}
+}
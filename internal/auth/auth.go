@@ -0,0 +1,203 @@
+// Package auth authenticates API callers against the configured tokens or
+// JWTs and carries the resulting identity through request contexts.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+
+	"gomcp-pilot/internal/config"
+)
+
+// ErrForbidden is returned when the caller's token does not permit an operation.
+var ErrForbidden = errors.New("forbidden")
+
+// LegacyTokenName is the identity of callers using the single auth_token.
+const LegacyTokenName = "auth_token"
+
+// Identity is an authenticated caller. A nil *Identity means authentication
+// is disabled (or the call did not come over HTTP) and permits everything.
+type Identity struct {
+	Name      string
+	scopes    map[string]bool
+	upstreams []string
+	tools     []string
+}
+
+// Can reports whether the identity holds scope.
+func (id *Identity) Can(scope string) bool {
+	return id == nil || id.scopes[scope]
+}
+
+// AllowsUpstream reports whether the identity may use upstream at all.
+func (id *Identity) AllowsUpstream(upstream string) bool {
+	return id == nil || matchAny(id.upstreams, upstream)
+}
+
+// AllowsTool reports whether the identity may see and use upstream/tool.
+func (id *Identity) AllowsTool(upstream, tool string) bool {
+	return id == nil || (matchAny(id.upstreams, upstream) && matchAny(id.tools, tool))
+}
+
+// Caller returns the token name for the audit log, or "" for a nil identity.
+func (id *Identity) Caller() string {
+	if id == nil {
+		return ""
+	}
+	return id.Name
+}
+
+func matchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying id.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the identity stored in ctx, or nil.
+func FromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(ctxKey{}).(*Identity)
+	return id
+}
+
+// HashSecret returns the form a token secret takes in the config's secret_hash.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return config.SecretHashPrefix + hex.EncodeToString(sum[:])
+}
+
+type credential struct {
+	hash []byte
+	id   *Identity
+}
+
+// Authenticator checks bearer secrets against the configured tokens and, if
+// configured, validates bearer JWTs.
+type Authenticator struct {
+	creds []credential
+	certs map[string]*Identity // by cert_subject
+	jwt   *jwtVerifier
+}
+
+// New builds an Authenticator from cfg.Tokens plus the legacy auth_token, which
+// is granted every scope.
+func New(cfg *config.Config) *Authenticator {
+	a := &Authenticator{certs: make(map[string]*Identity)}
+	if cfg.AuthToken != "" {
+		a.add(HashSecret(cfg.AuthToken), &Identity{Name: LegacyTokenName, scopes: allScopes()})
+	}
+	for _, t := range cfg.Tokens {
+		scopes := make(map[string]bool, len(t.Scopes))
+		for _, s := range t.Scopes {
+			scopes[s] = true
+		}
+		id := &Identity{Name: t.Name, scopes: scopes, upstreams: t.Upstreams, tools: t.Tools}
+		if t.SecretHash != "" {
+			a.add(t.SecretHash, id)
+		}
+		if t.CertSubject != "" {
+			a.certs[t.CertSubject] = id
+		}
+	}
+	if cfg.JWT != nil {
+		a.jwt = newJWTVerifier(cfg.JWT)
+	}
+	return a
+}
+
+// Reload builds the Authenticator for a reloaded config. The JWKS cache is
+// carried over when the JWT settings are unchanged.
+func (a *Authenticator) Reload(cfg *config.Config) *Authenticator {
+	next := New(cfg)
+	if a.jwt != nil && next.jwt != nil && a.jwt.cfg == next.jwt.cfg {
+		next.jwt = a.jwt
+	}
+	return next
+}
+
+func (a *Authenticator) add(hash string, id *Identity) {
+	a.creds = append(a.creds, credential{hash: []byte(hash), id: id})
+}
+
+func allScopes() map[string]bool {
+	m := make(map[string]bool, len(config.Scopes))
+	for _, s := range config.Scopes {
+		m[s] = true
+	}
+	return m
+}
+
+// Enabled reports whether any credential or JWT validation is configured.
+// Without one the API is open.
+func (a *Authenticator) Enabled() bool {
+	return len(a.creds) > 0 || len(a.certs) > 0 || a.jwt != nil
+}
+
+// AuthenticateCert returns the identity bound to a verified client
+// certificate's full subject or common name, or nil.
+func (a *Authenticator) AuthenticateCert(cert *x509.Certificate) *Identity {
+	if id, ok := a.certs[cert.Subject.String()]; ok {
+		return id
+	}
+	if cert.Subject.CommonName == "" {
+		return nil
+	}
+	return a.certs[cert.Subject.CommonName]
+}
+
+// ErrUnauthenticated is returned for a missing or unknown bearer secret.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// ErrInvalidJWT is wrapped, along with ErrUnauthenticated, by the errors for
+// bearer JWTs that fail validation.
+var ErrInvalidJWT = errors.New("invalid jwt")
+
+// Authenticate returns the identity for a bearer secret: a configured token,
+// or else a JWT accepted by the JWT settings. Errors explain why a JWT was
+// rejected; they are for logs, not for the caller.
+func (a *Authenticator) Authenticate(secret string) (*Identity, error) {
+	if secret == "" {
+		return nil, ErrUnauthenticated
+	}
+	if id := a.matchToken(secret); id != nil {
+		return id, nil
+	}
+	if a.jwt != nil && looksLikeJWT(secret) {
+		id, err := a.jwt.verify(secret)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w: %w", ErrUnauthenticated, ErrInvalidJWT, err)
+		}
+		return id, nil
+	}
+	return nil, ErrUnauthenticated
+}
+
+func (a *Authenticator) matchToken(secret string) *Identity {
+	hash := []byte(HashSecret(secret))
+	var found *Identity
+	// Compare against every credential so timing does not reveal which matched.
+	for _, c := range a.creds {
+		if subtle.ConstantTimeCompare(hash, c.hash) == 1 && found == nil {
+			found = c.id
+		}
+	}
+	return found
+}
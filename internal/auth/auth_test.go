@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+	"time"
+
+	"gomcp-pilot/internal/config"
+)
+
+func testAuthenticator() *Authenticator {
+	return New(&config.Config{
+		AuthToken: "legacy",
+		Tokens: []config.Token{
+			{Name: "ci", SecretHash: HashSecret("ci-secret"), Scopes: []string{config.ScopeList, config.ScopeCall}, Upstreams: []string{"fs", "git-*"}, Tools: []string{"read_*"}},
+			{Name: "auditor", SecretHash: HashSecret("audit-secret"), Scopes: []string{config.ScopeAdmin}},
+			{Name: "agent", CertSubject: "agent-1", Scopes: []string{config.ScopeCall}},
+		},
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	a := testAuthenticator()
+	if !a.Enabled() {
+		t.Fatal("authenticator with tokens is disabled")
+	}
+	tests := []struct {
+		secret string
+		want   string // token name; "" for rejected
+	}{
+		{"ci-secret", "ci"},
+		{"audit-secret", "auditor"},
+		{"legacy", LegacyTokenName},
+		{"", ""},
+		{"wrong", ""},
+		// The config holds the hash; presenting it does not authenticate.
+		{HashSecret("ci-secret"), ""},
+		{"ci-secret ", ""},
+	}
+	for _, tt := range tests {
+		id, err := a.Authenticate(tt.secret)
+		if tt.want == "" {
+			if id != nil || !errors.Is(err, ErrUnauthenticated) || errors.Is(err, ErrInvalidJWT) {
+				t.Errorf("Authenticate(%q) = %v, %v; want ErrUnauthenticated", tt.secret, id, err)
+			}
+			continue
+		}
+		if err != nil || id.Name != tt.want {
+			t.Errorf("Authenticate(%q) = %v, %v; want %s", tt.secret, id, err, tt.want)
+		}
+	}
+
+	if New(&config.Config{}).Enabled() {
+		t.Error("authenticator without credentials is enabled")
+	}
+}
+
+func TestAuthenticateCert(t *testing.T) {
+	a := testAuthenticator()
+	cert := func(subject pkix.Name) *x509.Certificate { return &x509.Certificate{Subject: subject} }
+	if id := a.AuthenticateCert(cert(pkix.Name{CommonName: "agent-1", Organization: []string{"Acme"}})); id == nil || id.Name != "agent" {
+		t.Errorf("common name: %v", id)
+	}
+	if id := a.AuthenticateCert(cert(pkix.Name{CommonName: "agent-2"})); id != nil {
+		t.Errorf("unknown subject authenticated as %s", id.Name)
+	}
+	if id := a.AuthenticateCert(cert(pkix.Name{})); id != nil {
+		t.Errorf("empty subject authenticated as %s", id.Name)
+	}
+}
+
+func TestIdentityPermissions(t *testing.T) {
+	a := testAuthenticator()
+	identity := func(secret string) *Identity {
+		t.Helper()
+		id, err := a.Authenticate(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	ci, auditor, legacy := identity("ci-secret"), identity("audit-secret"), identity("legacy")
+
+	scopes := []struct {
+		id    *Identity
+		scope string
+		want  bool
+	}{
+		{ci, config.ScopeList, true},
+		{ci, config.ScopeCall, true},
+		{ci, config.ScopeRead, false},
+		{ci, config.ScopeAdmin, false},
+		{auditor, config.ScopeAdmin, true},
+		{auditor, config.ScopeCall, false},
+		{legacy, config.ScopeAdmin, true},
+		{legacy, config.ScopeRead, true},
+		{nil, config.ScopeAdmin, true},
+	}
+	for _, tt := range scopes {
+		if got := tt.id.Can(tt.scope); got != tt.want {
+			t.Errorf("%s.Can(%s) = %v, want %v", tt.id.Caller(), tt.scope, got, tt.want)
+		}
+	}
+
+	tools := []struct {
+		id             *Identity
+		upstream, tool string
+		upstreamOK     bool
+		toolOK         bool
+	}{
+		{ci, "fs", "read_file", true, true},
+		{ci, "git-main", "read_log", true, true},
+		{ci, "fs", "write_file", true, false},
+		{ci, "db", "read_rows", false, false},
+		{ci, "git", "read_log", false, false},
+		{auditor, "db", "drop", true, true},
+		{nil, "db", "drop", true, true},
+	}
+	for _, tt := range tools {
+		if got := tt.id.AllowsUpstream(tt.upstream); got != tt.upstreamOK {
+			t.Errorf("%s.AllowsUpstream(%s) = %v, want %v", tt.id.Caller(), tt.upstream, got, tt.upstreamOK)
+		}
+		if got := tt.id.AllowsTool(tt.upstream, tt.tool); got != tt.toolOK {
+			t.Errorf("%s.AllowsTool(%s, %s) = %v, want %v", tt.id.Caller(), tt.upstream, tt.tool, got, tt.toolOK)
+		}
+	}
+}
+
+func TestAuthenticateRejectedJWT(t *testing.T) {
+	key := testEd25519Key(t)
+	srv := newJWKSServer(t, publicJWK("ed", key))
+	a := New(&config.Config{JWT: &config.JWT{JWKSURL: srv.URL, RefreshInterval: time.Hour, Issuer: "https://issuer.example", NameClaim: "sub"}})
+
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	_, err := a.Authenticate(mint(t, "ed", key, claims))
+	if !errors.Is(err, ErrUnauthenticated) || !errors.Is(err, ErrInvalidJWT) {
+		t.Errorf("err = %v, want an invalid JWT", err)
+	}
+	// Secrets that are not JWTs are not handed to the verifier.
+	if _, err := a.Authenticate("not-a-jwt"); !errors.Is(err, ErrUnauthenticated) || errors.Is(err, ErrInvalidJWT) {
+		t.Errorf("err = %v, want a plain ErrUnauthenticated", err)
+	}
+}
@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/logger"
+)
+
+const (
+	// jwksMinRefresh rate-limits refetching the key set for unknown key IDs.
+	jwksMinRefresh   = 30 * time.Second
+	jwksFetchTimeout = 10 * time.Second
+	jwksMaxSize      = 1 << 20
+)
+
+// jwk is a verification key from a JWKS.
+type jwk struct {
+	kid string
+	alg string // optional; restricts the key to one algorithm
+	key crypto.PublicKey
+}
+
+// keySet caches a JWKS loaded from a file or URL. It is refetched when older
+// than the refresh interval, and early when a token names a key it does not
+// hold, so keys rotated at the issuer are picked up. Fetches run without mu
+// held, one at a time; tokens signed with cached keys are verified while a
+// refresh is in flight.
+type keySet struct {
+	file, url string
+	refresh   time.Duration
+	client    *http.Client
+
+	mu          sync.Mutex
+	keys        []jwk // replaced on reload, never modified
+	fetched     time.Time
+	lastAttempt time.Time
+	inflight    chan struct{} // closed when the running fetch ends; nil if none
+}
+
+func newKeySet(cfg *config.JWT) *keySet {
+	return &keySet{
+		file:    cfg.JWKSFile,
+		url:     cfg.JWKSURL,
+		refresh: cfg.RefreshInterval,
+		client:  &http.Client{Timeout: jwksFetchTimeout},
+	}
+}
+
+// lookup returns the candidate keys for kid; an empty kid matches every key.
+func (ks *keySet) lookup(kid string) ([]jwk, error) {
+	ks.mu.Lock()
+	keys := ks.match(kid)
+	var wait <-chan struct{}
+	switch {
+	case len(keys) == 0 && (ks.inflight != nil || time.Since(ks.lastAttempt) > jwksMinRefresh):
+		// Nothing to verify with yet: wait for the fetch.
+		wait = ks.reload()
+	case time.Since(ks.fetched) > ks.refresh && time.Since(ks.lastAttempt) > jwksMinRefresh:
+		// The stale keys keep serving until the refresh lands.
+		ks.reload()
+	}
+	ks.mu.Unlock()
+
+	if wait != nil {
+		<-wait
+		ks.mu.Lock()
+		keys = ks.match(kid)
+		ks.mu.Unlock()
+	}
+	if len(keys) == 0 {
+		ks.mu.Lock()
+		loaded := !ks.fetched.IsZero()
+		ks.mu.Unlock()
+		if !loaded {
+			return nil, errors.New("no JWKS loaded")
+		}
+		return nil, fmt.Errorf("no key with kid %q", kid)
+	}
+	return keys, nil
+}
+
+// match returns the keys for kid. ks.mu must be held.
+func (ks *keySet) match(kid string) []jwk {
+	var out []jwk
+	for _, k := range ks.keys {
+		if kid == "" || k.kid == kid {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// reload starts refetching the key set unless a fetch is already running, and
+// returns a channel that is closed when the fetch ends. On failure the
+// previous keys stay in use. ks.mu must be held; the fetch itself runs
+// without it.
+func (ks *keySet) reload() <-chan struct{} {
+	if ks.inflight != nil {
+		return ks.inflight
+	}
+	done := make(chan struct{})
+	ks.inflight = done
+	ks.lastAttempt = time.Now()
+	go func() {
+		defer close(done)
+		keys, err := ks.fetch()
+
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+		ks.inflight = nil
+		if err != nil {
+			logger.Global.Warn("Failed to load JWKS, keeping previous keys",
+				zap.String("source", ks.file+ks.url),
+				zap.Error(err))
+			return
+		}
+		ks.keys = keys
+		ks.fetched = time.Now()
+	}()
+	return done
+}
+
+func (ks *keySet) fetch() ([]jwk, error) {
+	var data []byte
+	if ks.file != "" {
+		b, err := os.ReadFile(ks.file)
+		if err != nil {
+			return nil, err
+		}
+		data = b
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := ks.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GET %s: %s", ks.url, resp.Status)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, jwksMaxSize)); err != nil {
+			return nil, err
+		}
+	}
+	return parseJWKS(data)
+}
+
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS decodes the signature keys of a JWKS document. Keys of unsupported
+// types are skipped so that one exotic key does not disable the others.
+func parseJWKS(data []byte) ([]jwk, error) {
+	var doc struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+	var keys []jwk
+	for i, raw := range doc.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := raw.publicKey()
+		if err != nil {
+			logger.Global.Warn("Skipping JWKS key", zap.Int("index", i), zap.String("kid", raw.Kid), zap.Error(err))
+			continue
+		}
+		keys = append(keys, jwk{kid: raw.Kid, alg: raw.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no usable signature keys")
+	}
+	return keys, nil
+}
+
+func (raw rawJWK) publicKey() (crypto.PublicKey, error) {
+	switch raw.Kty {
+	case "RSA":
+		n, err := b64Int(raw.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := b64Int(raw.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		if n.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		var check ecdh.Curve
+		switch raw.Crv {
+		case "P-256":
+			curve, check = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, check = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, check = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", raw.Crv)
+		}
+		x, err := b64Decode(raw.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := b64Decode(raw.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC coordinates")
+		}
+		// ecdh rejects points that are not on the curve.
+		if _, err := check.NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	case "OKP":
+		if raw.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", raw.Crv)
+		}
+		x, err := b64Decode(raw.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", raw.Kty)
+}
+
+func b64Decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+func b64Int(s string) (*big.Int, error) {
+	b, err := b64Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
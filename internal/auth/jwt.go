@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"gomcp-pilot/internal/config"
+)
+
+// jwtVerifier validates bearer JWTs against a JWKS and maps their claims to
+// an Identity.
+type jwtVerifier struct {
+	cfg  config.JWT
+	keys *keySet
+	now  func() time.Time
+}
+
+func newJWTVerifier(cfg *config.JWT) *jwtVerifier {
+	return &jwtVerifier{cfg: *cfg, keys: newKeySet(cfg), now: time.Now}
+}
+
+// looksLikeJWT reports whether secret has the three dot-separated parts of a
+// compact JWS, so static token secrets never reach the JWT parser.
+func looksLikeJWT(secret string) bool {
+	return strings.Count(secret, ".") == 2
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// verify checks the token's signature and its iss, aud, exp and nbf claims.
+func (v *jwtVerifier) verify(token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed JWT")
+	}
+	var hdr jwtHeader
+	if err := decodeSegment(parts[0], &hdr); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	sig, err := b64Decode(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+
+	keys, err := v.keys.lookup(hdr.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, k := range keys {
+		if k.alg != "" && k.alg != hdr.Alg {
+			continue
+		}
+		if err := verifySignature(hdr.Alg, k.key, signed, sig); err == nil {
+			verified = true
+			break
+		} else if errors.Is(err, errUnsupportedAlg) {
+			return nil, err
+		}
+	}
+	if !verified {
+		return nil, errors.New("signature verification failed")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return v.identity(claims)
+}
+
+func (v *jwtVerifier) checkClaims(claims map[string]any) error {
+	now := v.now()
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return errors.New("missing exp claim")
+	}
+	if now.After(exp.Add(v.cfg.Leeway)) {
+		return fmt.Errorf("token expired at %s", exp.UTC().Format(time.RFC3339))
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(v.cfg.Leeway).Before(nbf) {
+		return fmt.Errorf("token not valid before %s", nbf.UTC().Format(time.RFC3339))
+	}
+	if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+		return fmt.Errorf("unexpected issuer %q", iss)
+	}
+	if !slices.Contains(stringList(claims["aud"]), v.cfg.Audience) {
+		return fmt.Errorf("audience does not include %q", v.cfg.Audience)
+	}
+	return nil
+}
+
+// identity maps the configured claims to an Identity. Scopes the gateway does
+// not know are dropped; absent upstream and tool claims allow everything, as
+// they do for static tokens.
+func (v *jwtVerifier) identity(claims map[string]any) (*Identity, error) {
+	name, _ := claims[v.cfg.NameClaim].(string)
+	if name == "" {
+		return nil, fmt.Errorf("missing %s claim", v.cfg.NameClaim)
+	}
+	scopes := make(map[string]bool)
+	granted := stringList(claims[v.cfg.ScopesClaim])
+	if s, ok := claims[v.cfg.ScopesClaim].(string); ok {
+		granted = strings.Fields(s)
+	}
+	for _, s := range granted {
+		if slices.Contains(config.Scopes, s) {
+			scopes[s] = true
+		}
+	}
+	return &Identity{
+		Name:      "jwt:" + name,
+		scopes:    scopes,
+		upstreams: stringList(claims[v.cfg.UpstreamsClaim]),
+		tools:     stringList(claims[v.cfg.ToolsClaim]),
+	}, nil
+}
+
+var errUnsupportedAlg = errors.New("unsupported JWT algorithm")
+
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	case "EdDSA":
+		k, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(k, signed, sig) {
+			return errors.New("bad signature")
+		}
+		return nil
+	default:
+		// Notably "none" and the HMAC algorithms, which a JWKS of public keys
+		// cannot verify.
+		return fmt.Errorf("%w %q", errUnsupportedAlg, alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(alg, "RS") {
+			return rsa.VerifyPKCS1v15(k, hash, digest, sig)
+		}
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.VerifyPSS(k, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if strings.HasPrefix(alg, "ES") && len(sig) == 2*size {
+			r := new(big.Int).SetBytes(sig[:size])
+			s := new(big.Int).SetBytes(sig[size:])
+			if ecdsa.Verify(k, digest, r, s) {
+				return nil
+			}
+		}
+	}
+	return errors.New("bad signature")
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := b64Decode(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func numericDate(v any) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)), true
+}
+
+// stringList accepts a claim that is a single string or an array of strings.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
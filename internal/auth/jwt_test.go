@@ -0,0 +1,398 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	// Set once: failed background JWKS fetches log while other tests run.
+	logger.Global = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+var (
+	rsaOnce sync.Once
+	rsaKey  *rsa.PrivateKey
+)
+
+// testRSAKey returns a 2048-bit key shared by the tests; generating one is slow.
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	rsaOnce.Do(func() {
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rsaKey = k
+	})
+	return rsaKey
+}
+
+func testEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, k, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// publicJWK returns the JWKS entry for key's public half.
+func publicJWK(kid string, key crypto.Signer) map[string]string {
+	switch k := key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "crv": "Ed25519", "kid": kid, "x": b64(k)}
+	}
+	panic("unsupported key")
+}
+
+// mint signs claims with key: RS256 for RSA keys, EdDSA for Ed25519 keys.
+func mint(t *testing.T, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	alg := "EdDSA"
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		alg = "RS256"
+	}
+	hdr, _ := json.Marshal(jwtHeader{Alg: alg, Kid: kid, Typ: "JWT"})
+	body, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := b64(hdr) + "." + b64(body)
+	var sig []byte
+	if alg == "RS256" {
+		sum := sha256.Sum256([]byte(signed))
+		sig, err = key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	} else {
+		sig, err = key.Sign(rand.Reader, []byte(signed), crypto.Hash(0))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + b64(sig)
+}
+
+// jwksServer serves a key set that tests can replace, counting fetches.
+type jwksServer struct {
+	*httptest.Server
+	fetches atomic.Int32
+
+	mu   sync.Mutex
+	keys []map[string]string
+}
+
+func newJWKSServer(t *testing.T, keys ...map[string]string) *jwksServer {
+	t.Helper()
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+var testNow = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newTestVerifier(t *testing.T, url string) *jwtVerifier {
+	t.Helper()
+	v := newJWTVerifier(&config.JWT{
+		JWKSURL:         url,
+		RefreshInterval: time.Hour,
+		Issuer:          "https://issuer.example",
+		Audience:        "gomcp",
+		Leeway:          30 * time.Second,
+		NameClaim:       "sub",
+		ScopesClaim:     "scope",
+		UpstreamsClaim:  "gomcp_upstreams",
+		ToolsClaim:      "gomcp_tools",
+	})
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub": "alice",
+		"iss": "https://issuer.example",
+		"aud": "gomcp",
+		"exp": testNow.Add(time.Minute).Unix(),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey := testRSAKey(t)
+	edKey := testEd25519Key(t)
+	other := testEd25519Key(t)
+	srv := newJWKSServer(t, publicJWK("rsa", rsaKey), publicJWK("ed", edKey))
+
+	with := func(k string, v any) map[string]any {
+		c := validClaims()
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+		return c
+	}
+	tests := []struct {
+		name    string
+		kid     string
+		key     crypto.Signer
+		claims  map[string]any
+		wantErr string
+	}{
+		{"RS256", "rsa", rsaKey, validClaims(), ""},
+		{"EdDSA", "ed", edKey, validClaims(), ""},
+		{"no kid tries every key", "", edKey, validClaims(), ""},
+		{"bad signature", "ed", other, validClaims(), "signature verification failed"},
+		{"key of another type", "rsa", edKey, validClaims(), "signature verification failed"},
+		{"wrong issuer", "rsa", rsaKey, with("iss", "https://evil.example"), "unexpected issuer"},
+		{"missing issuer", "ed", edKey, with("iss", nil), "unexpected issuer"},
+		{"wrong audience", "ed", edKey, with("aud", "other"), "audience does not include"},
+		{"audience list", "ed", edKey, with("aud", []string{"other", "gomcp"}), ""},
+		{"expired", "rsa", rsaKey, with("exp", testNow.Add(-time.Minute).Unix()), "token expired"},
+		{"expired within leeway", "rsa", rsaKey, with("exp", testNow.Add(-10*time.Second).Unix()), ""},
+		{"missing exp", "ed", edKey, with("exp", nil), "missing exp"},
+		{"not yet valid", "ed", edKey, with("nbf", testNow.Add(time.Minute).Unix()), "not valid before"},
+		{"nbf within leeway", "ed", edKey, with("nbf", testNow.Add(10*time.Second).Unix()), ""},
+		{"missing name", "ed", edKey, with("sub", nil), "missing sub claim"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVerifier(t, srv.URL)
+			id, err := v.verify(mint(t, tt.kid, tt.key, tt.claims))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if id.Name != "jwt:alice" {
+					t.Errorf("Name = %q, want jwt:alice", id.Name)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutLeeway(t *testing.T) {
+	key := testEd25519Key(t)
+	srv := newJWKSServer(t, publicJWK("ed", key))
+	v := newTestVerifier(t, srv.URL)
+	v.cfg.Leeway = 0
+
+	claims := validClaims()
+	claims["exp"] = testNow.Add(-time.Second).Unix()
+	if _, err := v.verify(mint(t, "ed", key, claims)); err == nil || !strings.Contains(err.Error(), "token expired") {
+		t.Errorf("err = %v, want token expired", err)
+	}
+}
+
+func TestVerifyRejectsUnsignedTokens(t *testing.T) {
+	key := testEd25519Key(t)
+	srv := newJWKSServer(t, publicJWK("ed", key))
+	v := newTestVerifier(t, srv.URL)
+
+	hdr, _ := json.Marshal(jwtHeader{Alg: "none", Kid: "ed"})
+	body, _ := json.Marshal(validClaims())
+	if _, err := v.verify(b64(hdr) + "." + b64(body) + "."); err == nil || !strings.Contains(err.Error(), "unsupported JWT algorithm") {
+		t.Errorf("err = %v, want unsupported JWT algorithm", err)
+	}
+}
+
+func TestUnknownKidRefetches(t *testing.T) {
+	oldKey := testEd25519Key(t)
+	newKey := testRSAKey(t)
+	srv := newJWKSServer(t, publicJWK("old", oldKey))
+	v := newTestVerifier(t, srv.URL)
+
+	if _, err := v.verify(mint(t, "old", oldKey, validClaims())); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.fetches.Load(); n != 1 {
+		t.Fatalf("fetches = %d, want 1", n)
+	}
+
+	// The issuer rotates in a new key. Within jwksMinRefresh of the last
+	// fetch the unknown kid is rejected without another request.
+	srv.setKeys(publicJWK("old", oldKey), publicJWK("new", newKey))
+	token := mint(t, "new", newKey, validClaims())
+	if _, err := v.verify(token); err == nil || !strings.Contains(err.Error(), `no key with kid "new"`) {
+		t.Fatalf("err = %v, want unknown kid", err)
+	}
+	if n := srv.fetches.Load(); n != 1 {
+		t.Fatalf("fetches = %d, want 1 while rate-limited", n)
+	}
+
+	v.keys.mu.Lock()
+	v.keys.lastAttempt = time.Now().Add(-jwksMinRefresh - time.Second)
+	v.keys.mu.Unlock()
+	if _, err := v.verify(token); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+	if n := srv.fetches.Load(); n != 2 {
+		t.Errorf("fetches = %d, want 2", n)
+	}
+
+	// The old key is then retired.
+	srv.setKeys(publicJWK("new", newKey))
+	v.keys.mu.Lock()
+	v.keys.fetched = time.Now().Add(-2 * time.Hour)
+	v.keys.lastAttempt = time.Now().Add(-jwksMinRefresh - time.Second)
+	v.keys.mu.Unlock()
+	if _, err := v.verify(token); err != nil {
+		t.Fatalf("stale keys during refresh: %v", err)
+	}
+	waitFetch(t, v.keys)
+	v.keys.mu.Lock()
+	v.keys.lastAttempt = time.Now().Add(-jwksMinRefresh - time.Second)
+	v.keys.mu.Unlock()
+	if _, err := v.verify(mint(t, "old", oldKey, validClaims())); err == nil {
+		t.Error("retired key still accepted")
+	}
+}
+
+// waitFetch waits for a fetch ks started in the background.
+func waitFetch(t *testing.T, ks *keySet) {
+	t.Helper()
+	ks.mu.Lock()
+	done := ks.inflight
+	ks.mu.Unlock()
+	if done == nil {
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("JWKS fetch did not finish")
+	}
+}
+
+func TestStaleKeysServeDuringRefresh(t *testing.T) {
+	key := testEd25519Key(t)
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []any{publicJWK("ed", key)}})
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	v := newTestVerifier(t, srv.URL)
+	token := mint(t, "ed", key, validClaims())
+	if _, err := v.verify(token); err != nil {
+		t.Fatal(err)
+	}
+
+	v.keys.mu.Lock()
+	v.keys.fetched = time.Now().Add(-2 * time.Hour)
+	v.keys.lastAttempt = time.Now().Add(-jwksMinRefresh - time.Second)
+	v.keys.mu.Unlock()
+
+	// The refresh hangs until release is closed; verification must not.
+	done := make(chan error)
+	go func() {
+		for range 5 {
+			if _, err := v.verify(token); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("verify blocked on the JWKS refresh")
+	}
+	if n := fetches.Load(); n > 2 {
+		t.Errorf("fetches = %d, want one refresh in flight", n)
+	}
+}
+
+func TestClaimsMapping(t *testing.T) {
+	key := testEd25519Key(t)
+	srv := newJWKSServer(t, publicJWK("ed", key))
+	v := newTestVerifier(t, srv.URL)
+
+	claims := validClaims()
+	claims["scope"] = "call tools:write"
+	claims["gomcp_upstreams"] = []string{"fs", "git-*"}
+	claims["gomcp_tools"] = "read_*"
+	id, err := v.verify(mint(t, "ed", key, claims))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Name != "jwt:alice" {
+		t.Errorf("Name = %q", id.Name)
+	}
+	if len(id.scopes) != 1 || !id.Can(config.ScopeCall) {
+		t.Errorf("scopes = %v, want only the known one", id.scopes)
+	}
+	tests := []struct {
+		upstream, tool string
+		want           bool
+	}{
+		{"fs", "read_file", true},
+		{"git-main", "read_log", true},
+		{"fs", "write_file", false},
+		{"db", "read_rows", false},
+	}
+	for _, tt := range tests {
+		if got := id.AllowsTool(tt.upstream, tt.tool); got != tt.want {
+			t.Errorf("AllowsTool(%s, %s) = %v, want %v", tt.upstream, tt.tool, got, tt.want)
+		}
+	}
+
+	// Without upstream and tool claims everything is allowed.
+	id, err = v.verify(mint(t, "ed", key, validClaims()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !id.AllowsTool("any", "thing") {
+		t.Error("token without upstream and tool claims is restricted")
+	}
+	if id.Can(config.ScopeCall) {
+		t.Error("token without a scope claim holds a scope")
+	}
+}
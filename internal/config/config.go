@@ -1,29 +1,245 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the runtime configuration loaded from YAML.
 type Config struct {
-	Port      int        `yaml:"port"`
-	AuthToken string     `yaml:"auth_token"`
+	Port int `yaml:"port"`
+	// ListenAddr overrides Port with a full listen address: "host:port" to bind
+	// one interface, or "unix:/path/to.sock" for a Unix socket.
+	ListenAddr string `yaml:"listen_addr"`
+	// PublicBaseURL is the address clients reach the gateway at (e.g. behind a
+	// reverse proxy), used for the SSE message endpoint. Unset, the endpoint is
+	// sent as a path that clients resolve against the URL they connected to.
+	PublicBaseURL string `yaml:"public_base_url"`
+	// TrustForwardedHeaders prefixes the SSE message endpoint with the
+	// X-Forwarded-Prefix a reverse proxy sends. Only enable it behind a proxy
+	// that sets the header.
+	TrustForwardedHeaders bool   `yaml:"trust_forwarded_headers"`
+	AuthToken             string `yaml:"auth_token"`
+	// TLSCert and TLSKey, if set, serve HTTPS instead of HTTP. With ClientCA,
+	// clients may also present a certificate signed by that CA, which
+	// authenticates them as the token whose cert_subject matches.
+	TLSCert  string `yaml:"tls_cert"`
+	TLSKey   string `yaml:"tls_key"`
+	ClientCA string `yaml:"client_ca"`
+	// Tokens are named API credentials with their own permissions. They may be
+	// combined with AuthToken, which keeps full access.
+	Tokens []Token `yaml:"tokens"`
+	// JWT, if set, also accepts bearer JWTs signed by a key in a JWKS.
+	JWT       *JWT       `yaml:"jwt"`
 	Upstreams []Upstream `yaml:"upstreams"`
+	// Rules decide whether tool calls are allowed, denied or need approval.
+	// The first matching rule wins; calls matching no rule fall back to the
+	// upstream's auto_approve setting.
+	Rules []Rule `yaml:"rules"`
+	// ApprovalMode decides what happens to calls needing approval when no TUI
+	// is attached (serve and stdio): deny (default), allow or queue.
+	ApprovalMode string `yaml:"approval_mode"`
+	// ApprovalTimeout bounds how long a call waits for a human decision before
+	// ApprovalTimeoutAction (deny by default, or allow) applies. Zero waits forever.
+	ApprovalTimeout       time.Duration `yaml:"approval_timeout"`
+	ApprovalTimeoutAction string        `yaml:"approval_timeout_action"`
+	// Store configures the database holding the audit log and grants.
+	Store Store `yaml:"store"`
+
+	// Path is the file the config was loaded from; used for hot reload.
+	Path string `yaml:"-"`
+}
+
+// Token is a named API credential. Only a hash of the secret is stored; see
+// `gomcp token new`. A token may instead (or also) be bound to a client
+// certificate subject when mutual TLS is enabled.
+type Token struct {
+	Name       string `yaml:"name"`
+	SecretHash string `yaml:"secret_hash"` // "sha256:" followed by the hex SHA-256 of the secret
+	// CertSubject matches a verified client certificate: either its common
+	// name ("agent-1") or its full subject ("CN=agent-1,O=Acme").
+	CertSubject string   `yaml:"cert_subject"`
+	Scopes      []string `yaml:"scopes"` // any of Scopes
+	// Upstreams and Tools are shell-style globs limiting which tools the token
+	// can see and call; empty allows all.
+	Upstreams []string `yaml:"upstreams"`
+	Tools     []string `yaml:"tools"`
+}
+
+// JWT configures validation of bearer JWTs, e.g. issued by an OIDC provider.
+// Claims named by the *Claim fields carry the caller's permissions.
+type JWT struct {
+	// Exactly one of JWKSFile and JWKSURL is set. The key set is refetched
+	// every RefreshInterval (default 1h) and when a token names an unknown key.
+	JWKSFile        string        `yaml:"jwks_file"`
+	JWKSURL         string        `yaml:"jwks_url"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+
+	Issuer   string        `yaml:"issuer"`
+	Audience string        `yaml:"audience"`
+	Leeway   time.Duration `yaml:"leeway"` // clock skew allowed for exp and nbf (default 30s)
+
+	NameClaim      string `yaml:"name_claim"`      // caller name for the audit log (default "sub")
+	ScopesClaim    string `yaml:"scopes_claim"`    // space-separated string or array of scopes (default "scope")
+	UpstreamsClaim string `yaml:"upstreams_claim"` // array of upstream globs (default "gomcp_upstreams"); absent allows all
+	ToolsClaim     string `yaml:"tools_claim"`     // array of tool globs (default "gomcp_tools"); absent allows all
 }
 
-// Upstream describes a single MCP server that will be launched via stdio.
+// Store configures the SQLite database and how long audit records are kept.
+// Changes take effect on restart.
+type Store struct {
+	// Path is the database file (default ~/.gomcp/audit.db). Relative paths
+	// are resolved against the config file's directory, so gateways with
+	// separate configs keep separate databases. MemoryStore keeps the
+	// database in memory for the life of the process.
+	Path string `yaml:"path"`
+	// RetentionDays prunes audit records older than this many days; 0 keeps
+	// them forever.
+	RetentionDays int `yaml:"retention_days"`
+	// MaxRows prunes the oldest audit records beyond this count, the record
+	// of the prune included; 0 is unlimited.
+	MaxRows int `yaml:"max_rows"`
+	// VacuumInterval is how often the database file is compacted to return the
+	// space pruned records took; 0 never compacts.
+	VacuumInterval time.Duration `yaml:"vacuum_interval"`
+	// CheckpointKey is a PEM-encoded Ed25519 private key (PKCS #8) that signs
+	// the checkpoints of the audit log's hash chain served at
+	// /audit/checkpoint. Relative paths resolve like Path. Unset disables
+	// checkpoints; the chain itself is always kept.
+	CheckpointKey string `yaml:"checkpoint_key"`
+}
+
+// MemoryStore is the Store.Path of a database that is not written to disk.
+const MemoryStore = ":memory:"
+
+// SecretHashPrefix starts every Token.SecretHash.
+const SecretHashPrefix = "sha256:"
+
+// Token scopes, i.e. the groups of endpoints a token may use.
+const (
+	ScopeList  = "list"  // list tools, resources and prompts
+	ScopeCall  = "call"  // call tools
+	ScopeRead  = "read"  // read resources and get prompts
+	ScopeAdmin = "admin" // approvals, grants and the audit log
+)
+
+// Scopes lists every token scope.
+var Scopes = []string{ScopeList, ScopeCall, ScopeRead, ScopeAdmin}
+
+// Approval modes for non-interactive runs.
+const (
+	ApprovalDeny  = "deny"
+	ApprovalAllow = "allow"
+	ApprovalQueue = "queue"
+)
+
+// Supported upstream transports.
+const (
+	TransportStdio          = "stdio"
+	TransportSSE            = "sse"
+	TransportStreamableHTTP = "streamable-http"
+)
+
+// Upstream describes a single MCP server, either launched locally via stdio or
+// reached over SSE / Streamable HTTP.
 type Upstream struct {
 	Name        string   `yaml:"name"`
+	Transport   string   `yaml:"transport"` // stdio (default), sse or streamable-http
 	Command     string   `yaml:"command"`
 	Args        []string `yaml:"args"`
 	Workdir     string   `yaml:"workdir"`
 	Env         []string `yaml:"env"`
 	AutoApprove bool     `yaml:"auto_approve"`
+
+	// URL and Headers are used by the sse and streamable-http transports.
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+
+	// HealthInterval is how often the supervisor pings the upstream.
+	HealthInterval time.Duration `yaml:"health_interval"`
+	// MaxRestarts bounds consecutive restarts before the upstream is marked
+	// failed. Unset means DefaultMaxRestarts; 0 disables restarts.
+	MaxRestarts *int `yaml:"max_restarts"`
+
+	// InitTimeout bounds the connect and initialize handshake.
+	InitTimeout time.Duration `yaml:"init_timeout"`
+	// CallTimeout bounds a tool call once it has been approved.
+	CallTimeout time.Duration `yaml:"call_timeout"`
+	// ToolTimeouts overrides CallTimeout for individual tools, keyed by tool name.
+	ToolTimeouts map[string]time.Duration `yaml:"tool_timeouts"`
+	// RequestTimeout bounds every other request: listing, resource reads and prompts.
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+
+	// SchemaValidation checks tool arguments against the tool's inputSchema
+	// before approval: off, warn (default, log only) or enforce (reject).
+	SchemaValidation string `yaml:"schema_validation"`
+}
+
+// Schema validation modes.
+const (
+	ValidationOff     = "off"
+	ValidationWarn    = "warn"
+	ValidationEnforce = "enforce"
+)
+
+// DefaultMaxRestarts is the restart budget of an upstream without max_restarts.
+const DefaultMaxRestarts = 5
+
+// RestartLimit returns how many consecutive restarts the supervisor may make.
+func (u Upstream) RestartLimit() int {
+	if u.MaxRestarts == nil {
+		return DefaultMaxRestarts
+	}
+	return *u.MaxRestarts
+}
+
+// TimeoutFor returns the call timeout for tool, honoring per-tool overrides.
+func (u Upstream) TimeoutFor(tool string) time.Duration {
+	if d, ok := u.ToolTimeouts[tool]; ok && d > 0 {
+		return d
+	}
+	return u.CallTimeout
+}
+
+// Policy rule actions.
+const (
+	ActionAllow = "allow"
+	ActionDeny  = "deny"
+	ActionAsk   = "ask"
+)
+
+// Rule matches tool calls by upstream, tool name and argument values.
+// Upstream and Tool are shell-style globs; empty matches anything.
+type Rule struct {
+	Name     string              `yaml:"name"`
+	Upstream string              `yaml:"upstream"`
+	Tool     string              `yaml:"tool"`
+	Args     map[string]ArgMatch `yaml:"args"` // keyed by argument name; dots address nested objects
+	Action   string              `yaml:"action"`
+	// Grants lets saved approval grants answer calls this ask rule matches.
+	// Without it the rule always asks; grants only answer calls no rule matched.
+	Grants bool `yaml:"grants"`
+}
+
+// ArgMatch constrains a single argument. Every non-empty field must match.
+type ArgMatch struct {
+	Equals     string `yaml:"equals"`
+	Prefix     string `yaml:"prefix"`
+	PathPrefix string `yaml:"path_prefix"` // cleaned path under this directory
+	Regex      string `yaml:"regex"`
 }
 
 // DefaultPath returns "./config.yaml" if present, otherwise ~/.config/gomcp/config.yaml.
@@ -52,6 +268,13 @@ func Load(path string) (*Config, error) {
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
+	if !filepath.IsAbs(cfg.Store.Path) && cfg.Store.Path != MemoryStore {
+		cfg.Store.Path = filepath.Join(filepath.Dir(path), cfg.Store.Path)
+	}
+	if cfg.Store.CheckpointKey != "" && !filepath.IsAbs(cfg.Store.CheckpointKey) {
+		cfg.Store.CheckpointKey = filepath.Join(filepath.Dir(path), cfg.Store.CheckpointKey)
+	}
+	cfg.Path = path
 	return &cfg, nil
 }
 
@@ -62,13 +285,242 @@ func (c *Config) validate() error {
 	if len(c.Upstreams) == 0 {
 		return errors.New("no upstreams configured")
 	}
-	for _, ups := range c.Upstreams {
+	if c.ListenAddr != "" {
+		if sock, ok := strings.CutPrefix(c.ListenAddr, UnixPrefix); ok {
+			if sock == "" {
+				return errors.New("listen_addr: missing socket path")
+			}
+		} else if _, _, err := net.SplitHostPort(c.ListenAddr); err != nil {
+			return fmt.Errorf("listen_addr: %w", err)
+		}
+	}
+	if c.PublicBaseURL != "" {
+		u, err := url.Parse(c.PublicBaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("public_base_url must be an http(s) URL without query, got %q", c.PublicBaseURL)
+		}
+		c.PublicBaseURL = strings.TrimSuffix(c.PublicBaseURL, "/")
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return errors.New("tls_cert and tls_key must be set together")
+	}
+	if c.ClientCA != "" && c.TLSCert == "" {
+		return errors.New("client_ca requires tls_cert and tls_key")
+	}
+	switch c.ApprovalMode {
+	case "":
+		c.ApprovalMode = ApprovalDeny
+	case ApprovalDeny, ApprovalAllow, ApprovalQueue:
+	default:
+		return fmt.Errorf("unknown approval_mode %q", c.ApprovalMode)
+	}
+	if c.ApprovalTimeout < 0 {
+		return errors.New("approval_timeout must not be negative")
+	}
+	switch c.ApprovalTimeoutAction {
+	case "":
+		c.ApprovalTimeoutAction = ActionDeny
+	case ActionDeny, ActionAllow:
+	default:
+		return fmt.Errorf("approval_timeout_action must be deny or allow, got %q", c.ApprovalTimeoutAction)
+	}
+	for i := range c.Upstreams {
+		ups := &c.Upstreams[i]
 		if ups.Name == "" {
 			return fmt.Errorf("upstream missing name")
 		}
-		if ups.Command == "" {
-			return fmt.Errorf("upstream %s missing command", ups.Name)
+		switch ups.Transport {
+		case "", TransportStdio:
+			ups.Transport = TransportStdio
+			if ups.Command == "" {
+				return fmt.Errorf("upstream %s missing command", ups.Name)
+			}
+		case TransportSSE, TransportStreamableHTTP:
+			if ups.URL == "" {
+				return fmt.Errorf("upstream %s missing url", ups.Name)
+			}
+		default:
+			return fmt.Errorf("upstream %s has unknown transport %q", ups.Name, ups.Transport)
+		}
+		if ups.HealthInterval < 0 {
+			return fmt.Errorf("upstream %s has a negative health_interval", ups.Name)
+		}
+		if ups.HealthInterval == 0 {
+			ups.HealthInterval = 15 * time.Second
+		}
+		if ups.MaxRestarts != nil && *ups.MaxRestarts < 0 {
+			return fmt.Errorf("upstream %s has a negative max_restarts", ups.Name)
+		}
+		if ups.InitTimeout < 0 || ups.CallTimeout < 0 || ups.RequestTimeout < 0 {
+			return fmt.Errorf("upstream %s has a negative timeout", ups.Name)
+		}
+		if ups.InitTimeout == 0 {
+			ups.InitTimeout = 60 * time.Second
 		}
+		if ups.CallTimeout == 0 {
+			ups.CallTimeout = 60 * time.Second
+		}
+		if ups.RequestTimeout == 0 {
+			ups.RequestTimeout = 10 * time.Second
+		}
+		switch ups.SchemaValidation {
+		case "":
+			ups.SchemaValidation = ValidationWarn
+		case ValidationOff, ValidationWarn, ValidationEnforce:
+		default:
+			return fmt.Errorf("upstream %s has unknown schema_validation %q", ups.Name, ups.SchemaValidation)
+		}
+		for tool, d := range ups.ToolTimeouts {
+			if d <= 0 {
+				return fmt.Errorf("upstream %s has invalid timeout for tool %s", ups.Name, tool)
+			}
+		}
+	}
+	if err := c.Store.validate(); err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+	if err := c.validateTokens(); err != nil {
+		return err
+	}
+	if c.JWT != nil {
+		if err := c.JWT.validate(); err != nil {
+			return fmt.Errorf("jwt: %w", err)
+		}
+	}
+	for i, r := range c.Rules {
+		switch r.Action {
+		case ActionAllow, ActionDeny, ActionAsk:
+		default:
+			return fmt.Errorf("rule %d has unknown action %q", i, r.Action)
+		}
+		if r.Grants && r.Action != ActionAsk {
+			return fmt.Errorf("rule %d: grants only applies to action %q", i, ActionAsk)
+		}
+		for arg, m := range r.Args {
+			if m.Regex == "" {
+				continue
+			}
+			if _, err := regexp.Compile(m.Regex); err != nil {
+				return fmt.Errorf("rule %d: invalid regex for %s: %w", i, arg, err)
+			}
+		}
+	}
+	return nil
+}
+
+// UnixPrefix marks a ListenAddr that is a Unix socket path.
+const UnixPrefix = "unix:"
+
+// Listen returns the network and address the HTTP gateway listens on.
+func (c *Config) Listen() (network, address string) {
+	if sock, ok := strings.CutPrefix(c.ListenAddr, UnixPrefix); ok {
+		return "unix", sock
+	}
+	if c.ListenAddr != "" {
+		return "tcp", c.ListenAddr
+	}
+	return "tcp", fmt.Sprintf(":%d", c.Port)
+}
+
+// TLS reports whether the HTTP gateway serves HTTPS.
+func (c *Config) TLS() bool {
+	return c.TLSCert != ""
+}
+
+func (c *Config) validateTokens() error {
+	names := make(map[string]bool, len(c.Tokens))
+	for i, t := range c.Tokens {
+		if t.Name == "" {
+			return fmt.Errorf("tokens[%d] missing name", i)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("duplicate token name %q", t.Name)
+		}
+		names[t.Name] = true
+
+		if t.SecretHash == "" && t.CertSubject == "" {
+			return fmt.Errorf("token %s needs a secret_hash or cert_subject", t.Name)
+		}
+		if t.SecretHash != "" {
+			digest, ok := strings.CutPrefix(t.SecretHash, SecretHashPrefix)
+			if b, err := hex.DecodeString(digest); !ok || err != nil || len(b) != sha256.Size {
+				return fmt.Errorf("token %s: secret_hash must be %q followed by 64 hex digits", t.Name, SecretHashPrefix)
+			}
+		}
+		if t.CertSubject != "" && c.ClientCA == "" {
+			return fmt.Errorf("token %s: cert_subject requires client_ca", t.Name)
+		}
+		if len(t.Scopes) == 0 {
+			return fmt.Errorf("token %s has no scopes", t.Name)
+		}
+		for _, s := range t.Scopes {
+			if !slices.Contains(Scopes, s) {
+				return fmt.Errorf("token %s has unknown scope %q", t.Name, s)
+			}
+		}
+		for _, pattern := range append(slices.Clone(t.Upstreams), t.Tools...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("token %s: invalid pattern %q: %w", t.Name, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Store) validate() error {
+	if s.RetentionDays < 0 || s.MaxRows < 0 || s.VacuumInterval < 0 {
+		return errors.New("retention_days, max_rows and vacuum_interval must not be negative")
+	}
+	if s.Path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("default path: %w", err)
+		}
+		s.Path = filepath.Join(home, ".gomcp", "audit.db")
+	} else if rest, ok := strings.CutPrefix(s.Path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("expand %s: %w", s.Path, err)
+		}
+		s.Path = filepath.Join(home, rest)
+	}
+	if rest, ok := strings.CutPrefix(s.CheckpointKey, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("expand %s: %w", s.CheckpointKey, err)
+		}
+		s.CheckpointKey = filepath.Join(home, rest)
+	}
+	return nil
+}
+
+func (j *JWT) validate() error {
+	if (j.JWKSFile == "") == (j.JWKSURL == "") {
+		return errors.New("set exactly one of jwks_file and jwks_url")
+	}
+	if j.Issuer == "" || j.Audience == "" {
+		return errors.New("issuer and audience are required")
+	}
+	if j.RefreshInterval < 0 || j.Leeway < 0 {
+		return errors.New("refresh_interval and leeway must not be negative")
+	}
+	if j.RefreshInterval == 0 {
+		j.RefreshInterval = time.Hour
+	}
+	if j.Leeway == 0 {
+		j.Leeway = 30 * time.Second
+	}
+	if j.NameClaim == "" {
+		j.NameClaim = "sub"
+	}
+	if j.ScopesClaim == "" {
+		j.ScopesClaim = "scope"
+	}
+	if j.UpstreamsClaim == "" {
+		j.UpstreamsClaim = "gomcp_upstreams"
+	}
+	if j.ToolsClaim == "" {
+		j.ToolsClaim = "gomcp_tools"
 	}
 	return nil
 }
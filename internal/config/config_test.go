@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// load writes doc to a config file and loads it.
+func load(t *testing.T, doc string) (*Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+func TestUpstreamValidation(t *testing.T) {
+	tests := []struct {
+		name, upstream, wantErr string
+	}{
+		{"negative health_interval", "health_interval: -1s", "negative health_interval"},
+		{"negative max_restarts", "max_restarts: -1", "negative max_restarts"},
+		{"negative init_timeout", "init_timeout: -1s", "negative timeout"},
+		{"negative call_timeout", "call_timeout: -1s", "negative timeout"},
+		{"negative request_timeout", "request_timeout: -1s", "negative timeout"},
+		{"zero tool timeout", "tool_timeouts: {slow: 0s}", "invalid timeout for tool slow"},
+		{"unknown transport", "transport: carrier-pigeon", "unknown transport"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(t, `
+store: {path: ":memory:"}
+upstreams:
+  - name: fs
+    command: mcp-fs
+    `+tt.upstream+`
+`)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpstreamTimeouts(t *testing.T) {
+	cfg, err := load(t, `
+store: {path: ":memory:"}
+upstreams:
+  - name: defaults
+    command: mcp-fs
+  - name: tuned
+    command: mcp-fs
+    health_interval: 5s
+    init_timeout: 2m
+    call_timeout: 90s
+    request_timeout: 3s
+    tool_timeouts:
+      build: 10m
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ups                          Upstream
+		health, init, call, request  time.Duration
+		buildTimeout, anotherTimeout time.Duration
+	}{
+		{cfg.Upstreams[0], 15 * time.Second, time.Minute, time.Minute, 10 * time.Second, time.Minute, time.Minute},
+		{cfg.Upstreams[1], 5 * time.Second, 2 * time.Minute, 90 * time.Second, 3 * time.Second, 10 * time.Minute, 90 * time.Second},
+	}
+	for _, tt := range tests {
+		u := tt.ups
+		if u.HealthInterval != tt.health || u.InitTimeout != tt.init || u.CallTimeout != tt.call || u.RequestTimeout != tt.request {
+			t.Errorf("%s: health %s, init %s, call %s, request %s; want %s, %s, %s, %s", u.Name,
+				u.HealthInterval, u.InitTimeout, u.CallTimeout, u.RequestTimeout, tt.health, tt.init, tt.call, tt.request)
+		}
+		if got := u.TimeoutFor("build"); got != tt.buildTimeout {
+			t.Errorf("%s: TimeoutFor(build) = %s, want %s", u.Name, got, tt.buildTimeout)
+		}
+		if got := u.TimeoutFor("another"); got != tt.anotherTimeout {
+			t.Errorf("%s: TimeoutFor(another) = %s, want %s", u.Name, got, tt.anotherTimeout)
+		}
+	}
+}
@@ -26,6 +26,16 @@ type LogEntry struct {
 	Fields    map[string]interface{}
 }
 
+// Send queues an entry for the TUI. When nothing drains LogChan (headless and
+// stdio modes) or the TUI falls behind, the entry is dropped instead of
+// blocking the caller; the log file still has it.
+func Send(e LogEntry) {
+	select {
+	case LogChan <- e:
+	default:
+	}
+}
+
 // ChannelSink implements zapcore.WriteSyncer to pipe logs to the TUI channel.
 type ChannelSink struct{}
 
@@ -39,11 +49,11 @@ func (cs *ChannelSink) Write(p []byte) (n int, err error) {
 	
 	// Create a simple entry. We can improve parsing if we want structured data in TUI.
 	// For now, let's just send the raw text or try to make it look decent.
-	LogChan <- LogEntry{
+	Send(LogEntry{
 		Level:     "INFO", // Default, hard to parse back without custom encoder
 		Message:   msg,
 		Timestamp: time.Now(),
-	}
+	})
 	return len(p), nil
 }
 
@@ -119,12 +129,12 @@ func (c *TUICore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
 		fieldMap[k] = v
 	}
 
-	LogChan <- LogEntry{
+	Send(LogEntry{
 		Level:     ent.Level.String(),
 		Message:   ent.Message,
 		Timestamp: ent.Time,
 		Fields:    fieldMap,
-	}
+	})
 	return nil
 }
 
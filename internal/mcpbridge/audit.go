@@ -0,0 +1,35 @@
+package mcpbridge
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"gomcp-pilot/internal/process"
+)
+
+// withSession tags ctx with the caller's MCP session for the audit log.
+func withSession(ctx context.Context) context.Context {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return process.WithSessionID(ctx, session.SessionID())
+	}
+	return ctx
+}
+
+// addListAudit registers hooks that record the listings clients make. They
+// must be added after addListFilters so the counts match what clients got.
+func addListAudit(hooks *server.Hooks, pm *process.Manager) {
+	hooks.AddAfterListTools(func(ctx context.Context, _ any, _ *mcp.ListToolsRequest, res *mcp.ListToolsResult) {
+		pm.RecordList(withSession(ctx), process.OpListTools, "", len(res.Tools), nil)
+	})
+	hooks.AddAfterListResources(func(ctx context.Context, _ any, _ *mcp.ListResourcesRequest, res *mcp.ListResourcesResult) {
+		pm.RecordList(withSession(ctx), process.OpListResources, "", len(res.Resources), nil)
+	})
+	hooks.AddAfterListResourceTemplates(func(ctx context.Context, _ any, _ *mcp.ListResourceTemplatesRequest, res *mcp.ListResourceTemplatesResult) {
+		pm.RecordList(withSession(ctx), process.OpListResourceTemplates, "", len(res.ResourceTemplates), nil)
+	})
+	hooks.AddAfterListPrompts(func(ctx context.Context, _ any, _ *mcp.ListPromptsRequest, res *mcp.ListPromptsResult) {
+		pm.RecordList(withSession(ctx), process.OpListPrompts, "", len(res.Prompts), nil)
+	})
+}
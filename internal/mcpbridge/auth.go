@@ -0,0 +1,130 @@
+package mcpbridge
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"gomcp-pilot/internal/auth"
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/process"
+)
+
+// splitName splits a gateway name such as "filesystem/read_file" into the
+// upstream and the upstream's own name.
+func splitName(name string) (upstream, rest string) {
+	upstream, rest, _ = strings.Cut(name, "/")
+	return upstream, rest
+}
+
+// filterTools hides the tools the caller's token may not use.
+func filterTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	id := auth.FromContext(ctx)
+	if id == nil {
+		return tools
+	}
+	if !id.Can(config.ScopeList) && !id.Can(config.ScopeCall) {
+		return nil
+	}
+	visible := make([]mcp.Tool, 0, len(tools))
+	for _, t := range tools {
+		if id.AllowsTool(splitName(t.Name)) {
+			visible = append(visible, t)
+		}
+	}
+	return visible
+}
+
+// addListFilters registers hooks that drop resources, templates and prompts
+// of upstreams the caller's token may not use from list results.
+func addListFilters(hooks *server.Hooks, pm *process.Manager) {
+	hooks.AddAfterListResources(func(ctx context.Context, _ any, _ *mcp.ListResourcesRequest, res *mcp.ListResourcesResult) {
+		id := auth.FromContext(ctx)
+		if id == nil {
+			return
+		}
+		kept := make([]mcp.Resource, 0, len(res.Resources))
+		for _, r := range res.Resources {
+			if id.Can(config.ScopeList) && id.AllowsUpstream(pm.ResourceOwner(r.URI)) {
+				kept = append(kept, r)
+			}
+		}
+		res.Resources = kept
+	})
+
+	hooks.AddAfterListResourceTemplates(func(ctx context.Context, _ any, _ *mcp.ListResourceTemplatesRequest, res *mcp.ListResourceTemplatesResult) {
+		id := auth.FromContext(ctx)
+		if id == nil {
+			return
+		}
+		templates, _ := pm.ListResourceTemplates("")
+		owners := make(map[string]string, len(templates))
+		for _, t := range templates {
+			owners[t.UriTemplate] = t.Upstream
+		}
+		kept := make([]mcp.ResourceTemplate, 0, len(res.ResourceTemplates))
+		for _, t := range res.ResourceTemplates {
+			var tmpl string
+			if t.URITemplate != nil {
+				tmpl = t.URITemplate.Raw()
+			}
+			if id.Can(config.ScopeList) && id.AllowsUpstream(owners[tmpl]) {
+				kept = append(kept, t)
+			}
+		}
+		res.ResourceTemplates = kept
+	})
+
+	hooks.AddAfterListPrompts(func(ctx context.Context, _ any, _ *mcp.ListPromptsRequest, res *mcp.ListPromptsResult) {
+		id := auth.FromContext(ctx)
+		if id == nil {
+			return
+		}
+		kept := make([]mcp.Prompt, 0, len(res.Prompts))
+		for _, p := range res.Prompts {
+			upstream, _ := splitName(p.Name)
+			if id.Can(config.ScopeList) && id.AllowsUpstream(upstream) {
+				kept = append(kept, p)
+			}
+		}
+		res.Prompts = kept
+	})
+}
+
+// sessionIdentities remembers the token each MCP session connected with, so
+// upstream notifications reach only the sessions allowed to see them.
+type sessionIdentities struct {
+	mu  sync.RWMutex
+	ids map[string]*auth.Identity // by session ID; nil when auth is off
+}
+
+func newSessionIdentities(hooks *server.Hooks) *sessionIdentities {
+	si := &sessionIdentities{ids: make(map[string]*auth.Identity)}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		si.mu.Lock()
+		si.ids[session.SessionID()] = auth.FromContext(ctx)
+		si.mu.Unlock()
+	})
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		si.mu.Lock()
+		delete(si.ids, session.SessionID())
+		si.mu.Unlock()
+	})
+	return si
+}
+
+// allowing returns the sessions whose token may use upstream.
+func (si *sessionIdentities) allowing(upstream string) []string {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	var sessions []string
+	for session, id := range si.ids {
+		if id.AllowsUpstream(upstream) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
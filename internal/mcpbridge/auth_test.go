@@ -0,0 +1,88 @@
+package mcpbridge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"gomcp-pilot/internal/auth"
+	"gomcp-pilot/internal/config"
+)
+
+type fakeSession struct {
+	id string
+	ch chan mcp.JSONRPCNotification
+}
+
+func newFakeSession(id string) *fakeSession {
+	return &fakeSession{id: id, ch: make(chan mcp.JSONRPCNotification, 4)}
+}
+
+func (f *fakeSession) Initialize()                                         {}
+func (f *fakeSession) Initialized() bool                                   { return true }
+func (f *fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return f.ch }
+func (f *fakeSession) SessionID() string                                   { return f.id }
+
+func TestForwardNotificationHonorsTokenScope(t *testing.T) {
+	authn := auth.New(&config.Config{Tokens: []config.Token{
+		{Name: "alpha-only", SecretHash: auth.HashSecret("a"), Scopes: []string{config.ScopeList}, Upstreams: []string{"alpha"}},
+		{Name: "beta-only", SecretHash: auth.HashSecret("b"), Scopes: []string{config.ScopeList}, Upstreams: []string{"beta"}},
+	}})
+	identity := func(secret string) *auth.Identity {
+		id, err := authn.Authenticate(secret)
+		if err != nil {
+			t.Fatalf("authenticate %s: %v", secret, err)
+		}
+		return id
+	}
+
+	hooks := &server.Hooks{}
+	sessions := newSessionIdentities(hooks)
+	s := server.NewMCPServer("test", "0", server.WithHooks(hooks))
+
+	alpha, beta, open := newFakeSession("alpha"), newFakeSession("beta"), newFakeSession("open")
+	for _, reg := range []struct {
+		session *fakeSession
+		ctx     context.Context
+	}{
+		{alpha, auth.NewContext(context.Background(), identity("a"))},
+		{beta, auth.NewContext(context.Background(), identity("b"))},
+		{open, context.Background()}, // auth disabled, e.g. stdio
+	} {
+		if err := s.RegisterSession(reg.ctx, reg.session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n := mcp.JSONRPCNotification{Notification: mcp.Notification{
+		Method: "notifications/message",
+		Params: mcp.NotificationParams{AdditionalFields: map[string]any{"level": "info", "data": "secret path"}},
+	}}
+	forwardNotification(s, nil, sessions, "alpha", n)
+
+	got := func(f *fakeSession) bool {
+		select {
+		case <-f.ch:
+			return true
+		default:
+			return false
+		}
+	}
+	if !got(alpha) {
+		t.Error("session allowed to use alpha did not get the notification")
+	}
+	if got(beta) {
+		t.Error("session scoped to beta got alpha's notification")
+	}
+	if !got(open) {
+		t.Error("session without auth did not get the notification")
+	}
+
+	s.UnregisterSession(context.Background(), "alpha")
+	forwardNotification(s, nil, sessions, "alpha", n)
+	if got(alpha) {
+		t.Error("unregistered session still gets notifications")
+	}
+}
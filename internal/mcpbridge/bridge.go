@@ -3,32 +3,95 @@ package mcpbridge
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-
-	"time"
+	"os"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
 
+	"gomcp-pilot/internal/logger"
 	"gomcp-pilot/internal/process"
-	"gomcp-pilot/internal/store"
+	"gomcp-pilot/internal/schema"
 )
 
 // NewServer builds an MCP server that forwards calls to upstream MCP servers via the process manager.
 func NewServer(pm *process.Manager) (*server.MCPServer, error) {
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(stampRequestID)
+	addListFilters(hooks, pm)
+	addListAudit(hooks, pm)
+	sessions := newSessionIdentities(hooks)
+
 	s := server.NewMCPServer(
 		"gomcp-pilot",
 		"0.1.0",
 		server.WithToolCapabilities(true),
 		server.WithResourceCapabilities(true, true),
+		server.WithPromptCapabilities(true),
 		server.WithRecovery(),
+		server.WithHooks(hooks),
+		server.WithToolFilter(filterTools),
 	)
+	s.AddNotificationHandler("notifications/cancelled", inflight.handleCancelled)
+
+	if err := Sync(s, pm); err != nil {
+		return nil, err
+	}
+	pm.SetNotifier(func(upstream string, n mcp.JSONRPCNotification) {
+		forwardNotification(s, pm, sessions, upstream, n)
+	})
+	return s, nil
+}
+
+// forwardNotification re-emits an upstream notification to the downstream
+// clients whose token may use the upstream. list_changed notifications are
+// answered by re-syncing registrations, which in turn notifies clients of the
+// gateway's own (prefixed) lists.
+func forwardNotification(s *server.MCPServer, pm *process.Manager, sessions *sessionIdentities, upstream string, n mcp.JSONRPCNotification) {
+	switch n.Method {
+	case mcp.MethodNotificationToolsListChanged,
+		mcp.MethodNotificationPromptsListChanged,
+		mcp.MethodNotificationResourcesListChanged:
+		if err := Sync(s, pm); err != nil {
+			logger.Global.Warn("Failed to re-sync after upstream list_changed",
+				zap.String("upstream", upstream),
+				zap.Error(err))
+		}
+		return
+	}
+
+	params := make(map[string]any, len(n.Params.AdditionalFields)+1)
+	for k, v := range n.Params.AdditionalFields {
+		params[k] = v
+	}
+	if n.Params.Meta != nil {
+		params["_meta"] = n.Params.Meta
+	}
+	if n.Method == "notifications/message" {
+		// Tag log lines with their origin so clients can tell upstreams apart.
+		if name, ok := params["logger"].(string); ok && name != "" {
+			params["logger"] = upstream + "/" + name
+		} else {
+			params["logger"] = upstream
+		}
+	}
+	for _, session := range sessions.allowing(upstream) {
+		// Sessions that have not finished initializing are skipped.
+		_ = s.SendNotificationToSpecificClient(session, n.Method, params)
+	}
+}
 
+// Sync replaces the tools, resources, resource templates and prompts registered on s with the ones currently
+// exposed by the process manager. Connected clients receive list_changed notifications.
+func Sync(s *server.MCPServer, pm *process.Manager) error {
 	tools, err := pm.ListTools("")
 	if err != nil {
-		return nil, fmt.Errorf("list tools: %w", err)
+		return fmt.Errorf("list tools: %w", err)
 	}
 
+	var serverTools []server.ServerTool
 	for _, t := range tools {
 		upstreamName := t.Upstream
 		toolName := t.Name
@@ -36,44 +99,53 @@ func NewServer(pm *process.Manager) (*server.MCPServer, error) {
 			fmt.Sprintf("%s/%s", upstreamName, toolName),
 			mcp.WithDescription(t.Description),
 		)
-		// Preserve structured schema; avoid setting RawInputSchema to prevent conflicts.
+		// Pass the schema on as the upstream sent it. RawInputSchema excludes
+		// InputSchema, which mcp.NewTool presets.
 		if t.InputSchema != nil {
 			if b, err := json.Marshal(t.InputSchema); err == nil {
-				_ = json.Unmarshal(b, &mcpTool.InputSchema)
+				mcpTool.InputSchema = mcp.ToolInputSchema{}
+				mcpTool.RawInputSchema = b
 			}
 		}
 
 		handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			start := time.Now()
-			argsBytes, _ := json.Marshal(req.GetRawArguments())
-			argsStr := string(argsBytes)
+			ctx, done := inflight.track(ctx, req)
+			defer done()
+			ctx = withSession(ctx)
 
-			result, err := pm.CallTool(ctx, process.CallRequest{
+			callReq := process.CallRequest{
 				Upstream:  upstreamName,
 				Tool:      toolName,
 				Arguments: req.GetRawArguments(),
-			})
-
-			duration := time.Since(start)
-			status := "success"
-			errStr := ""
-			if err != nil {
-				status = "error"
-				errStr = err.Error()
+			}
+			if req.Params.Meta != nil && req.Params.Meta.ProgressToken != nil {
+				// Relay upstream progress to the calling session under the client's own token.
+				clientToken := req.Params.Meta.ProgressToken
+				callReq.OnProgress = func(params map[string]any) {
+					params["progressToken"] = clientToken
+					_ = s.SendNotificationToClient(ctx, "notifications/progress", params)
+				}
 			}
 
-			// Async log to not block response too much, or sync for safety?
-			// Sync is safer for audit.
-			_ = store.RecordCall(upstreamName, toolName, argsStr, status, errStr, duration)
-
+			// The manager records the call in the audit log.
+			result, err := pm.CallTool(ctx, callReq)
 			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
+				res := mcp.NewToolResultError(err.Error())
+				var invalid schema.Errors
+				if errors.As(err, &invalid) {
+					// Precheck answers invalid arguments with an error before
+					// they get here, unless the schema changed in between.
+					// Lets the model see exactly which fields to fix.
+					res.StructuredContent = map[string]any{"errors": invalid}
+				}
+				return res, nil
 			}
 			return result, nil
 		}
 
-		s.AddTool(mcpTool, handler)
+		serverTools = append(serverTools, server.ServerTool{Tool: mcpTool, Handler: handler})
 	}
+	s.SetTools(serverTools...)
 
 	// Register Resource Handlers
 	resources, err := pm.ListResources("")
@@ -83,6 +155,7 @@ func NewServer(pm *process.Manager) (*server.MCPServer, error) {
 		// Default to ignoring error and just registering what we have
 	}
 
+	var serverResources []server.ServerResource
 	for _, r := range resources {
 		resource := mcp.Resource{
 			URI:         r.Uri,
@@ -93,24 +166,81 @@ func NewServer(pm *process.Manager) (*server.MCPServer, error) {
 
 		// Explicitly type the handler to get better error messages if signature mismatches
 		var handler server.ResourceHandlerFunc = func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-			res, err := pm.ReadResource(ctx, req.Params.URI)
+			res, err := pm.ReadResource(withSession(ctx), req.Params.URI)
 			if err != nil {
 				return nil, err
 			}
 			return res.Contents, nil
 		}
 
-		s.AddResource(resource, handler)
+		serverResources = append(serverResources, server.ServerResource{Resource: resource, Handler: handler})
 	}
+	s.SetResources(serverResources...)
 
-	return s, nil
+	// Register Resource Template Handlers; reads are routed by the manager's template matching
+	templates, err := pm.ListResourceTemplates("")
+	if err != nil {
+		return fmt.Errorf("list resource templates: %w", err)
+	}
+
+	var serverTemplates []server.ServerResourceTemplate
+	for _, rt := range templates {
+		template := mcp.NewResourceTemplate(rt.UriTemplate, rt.Name,
+			mcp.WithTemplateDescription(rt.Description),
+			mcp.WithTemplateMIMEType(rt.MimeType),
+		)
+
+		var handler server.ResourceTemplateHandlerFunc = func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			res, err := pm.ReadResource(withSession(ctx), req.Params.URI)
+			if err != nil {
+				return nil, err
+			}
+			return res.Contents, nil
+		}
+
+		serverTemplates = append(serverTemplates, server.ServerResourceTemplate{Template: template, Handler: handler})
+	}
+	s.SetResourceTemplates(serverTemplates...)
+
+	// Register Prompt Handlers, prefixed by upstream like tools
+	prompts, err := pm.ListPrompts("")
+	if err != nil {
+		return fmt.Errorf("list prompts: %w", err)
+	}
+
+	var serverPrompts []server.ServerPrompt
+	for _, p := range prompts {
+		upstreamName := p.Upstream
+		promptName := p.Name
+		prompt := mcp.Prompt{
+			Name:        fmt.Sprintf("%s/%s", upstreamName, promptName),
+			Description: p.Description,
+			Arguments:   p.Arguments,
+		}
+
+		var handler server.PromptHandlerFunc = func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			return pm.GetPrompt(withSession(ctx), upstreamName, promptName, req.Params.Arguments)
+		}
+
+		serverPrompts = append(serverPrompts, server.ServerPrompt{Prompt: prompt, Handler: handler})
+	}
+	s.SetPrompts(serverPrompts...)
+
+	return nil
 }
 
 // ServeStdio blocks serving MCP over stdio. The server will exit when stdin closes.
-func ServeStdio(ctx context.Context, srv *server.MCPServer) error {
+func ServeStdio(ctx context.Context, srv *server.MCPServer, pm *process.Manager) error {
+	stdio := server.NewStdioServer(srv)
+	stdio.SetContextFunc(func(ctx context.Context) context.Context {
+		return process.WithTransport(ctx, process.TransportStdio)
+	})
+	out := &lockedWriter{w: os.Stdout}
+	in := precheckLines(process.WithTransport(ctx, process.TransportStdio), pm, os.Stdin, out)
+
 	errCh := make(chan error, 1)
 	go func() {
-		errCh <- server.ServeStdio(srv)
+		errCh <- stdio.Listen(ctx, in, out)
 	}()
 
 	select {
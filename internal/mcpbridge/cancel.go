@@ -0,0 +1,96 @@
+package mcpbridge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/logger"
+)
+
+// requestIDHeader carries the downstream JSON-RPC id from the BeforeCallTool
+// hook to the tool handler, which mcp-go otherwise does not expose.
+const requestIDHeader = "X-Gomcp-Request-Id"
+
+// callRegistry tracks in-flight tool calls so notifications/cancelled from a
+// client can abort them. Keys combine the session ID with the JSON-encoded
+// request id, since ids are only unique within a session.
+type callRegistry struct {
+	mu    sync.Mutex
+	calls map[string]context.CancelFunc
+}
+
+var inflight = &callRegistry{calls: make(map[string]context.CancelFunc)}
+
+func callKey(ctx context.Context, id any) string {
+	var session string
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		session = cs.SessionID()
+	}
+	b, _ := json.Marshal(id)
+	return session + "|" + string(b)
+}
+
+// stampRequestID is a BeforeCallTool hook recording the request id on the request.
+func stampRequestID(_ context.Context, id any, req *mcp.CallToolRequest) {
+	b, err := json.Marshal(id)
+	if err != nil {
+		return
+	}
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set(requestIDHeader, string(b))
+}
+
+// track returns a context that is cancelled when the client cancels req, and
+// a func that must be called once the call has finished.
+func (r *callRegistry) track(ctx context.Context, req mcp.CallToolRequest) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	if req.Header == nil || req.Header.Get(requestIDHeader) == "" {
+		return ctx, cancel
+	}
+	var id any
+	if err := json.Unmarshal([]byte(req.Header.Get(requestIDHeader)), &id); err != nil {
+		return ctx, cancel
+	}
+	key := callKey(ctx, id)
+
+	r.mu.Lock()
+	r.calls[key] = cancel
+	r.mu.Unlock()
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.calls, key)
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// handleCancelled is registered for notifications/cancelled from downstream clients.
+func (r *callRegistry) handleCancelled(ctx context.Context, n mcp.JSONRPCNotification) {
+	id, ok := n.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+	key := callKey(ctx, id)
+
+	r.mu.Lock()
+	cancel := r.calls[key]
+	delete(r.calls, key)
+	r.mu.Unlock()
+	if cancel == nil {
+		// Already finished, or not a tool call.
+		return
+	}
+	reason, _ := n.Params.AdditionalFields["reason"].(string)
+	logger.Global.Info("Client cancelled tool call",
+		zap.Any("request_id", id),
+		zap.String("reason", reason))
+	cancel()
+}
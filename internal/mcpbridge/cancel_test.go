@@ -0,0 +1,105 @@
+package mcpbridge
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/logger"
+	"gomcp-pilot/internal/process"
+	"gomcp-pilot/internal/store"
+)
+
+func TestClientCancellationReachesUpstream(t *testing.T) {
+	logger.Global = zap.NewNop()
+	if err := store.InitStore(store.Memory); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		store.Close()
+		store.DB = nil
+	})
+
+	// The upstream's tool runs until its request goes away and reports the
+	// cancellations it is sent.
+	started := make(chan struct{})
+	cancelled := make(chan mcp.NotificationParams, 1)
+	up := server.NewMCPServer("upstream", "0", server.WithToolCapabilities(false))
+	up.AddTool(mcp.NewTool("slow"), func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	up.AddNotificationHandler("notifications/cancelled", func(_ context.Context, n mcp.JSONRPCNotification) {
+		cancelled <- n.Params
+	})
+	ts := server.NewTestStreamableHTTPServer(up)
+	t.Cleanup(ts.Close)
+
+	pm := process.NewManager()
+	err := pm.StartAll(context.Background(), &config.Config{Upstreams: []config.Upstream{{
+		Name:           "up",
+		Transport:      config.TransportStreamableHTTP,
+		URL:            ts.URL,
+		AutoApprove:    true,
+		HealthInterval: time.Hour,
+		InitTimeout:    5 * time.Second,
+		CallTimeout:    time.Minute,
+		RequestTimeout: 5 * time.Second,
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pm.StopAll)
+	gw, err := NewServer(pm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	done := make(chan mcp.JSONRPCMessage)
+	go func() {
+		done <- gw.HandleMessage(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"up/slow","arguments":{}}}`))
+	}()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("call did not reach the upstream")
+	}
+	gw.HandleMessage(ctx, json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7,"reason":"user pressed stop"}}`))
+
+	select {
+	case p := <-cancelled:
+		if reason := p.AdditionalFields["reason"]; reason != "request cancelled by client" {
+			t.Errorf("upstream told %v", reason)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream was not told of the cancellation")
+	}
+	select {
+	case resp := <-done:
+		res, ok := resp.(mcp.JSONRPCResponse)
+		if !ok {
+			t.Fatalf("response = %#v", resp)
+		}
+		if r, ok := res.Result.(mcp.CallToolResult); !ok || !r.IsError {
+			t.Errorf("result = %#v, want a tool error", res.Result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled call did not return")
+	}
+
+	calls, _, err := store.QueryCalls(store.CallFilter{Operation: process.OpCallTool})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || calls[0].Tool != "slow" || calls[0].Status != store.StatusCancelled {
+		t.Errorf("audit records = %+v, want one cancelled call", calls)
+	}
+}
@@ -0,0 +1,92 @@
+package mcpbridge
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"gomcp-pilot/internal/process"
+	"gomcp-pilot/internal/schema"
+)
+
+// Arguments that fail a tool's schema under schema_validation: enforce are
+// answered with a JSON-RPC "invalid params" error whose data lists the failing
+// JSON Pointers. mcp-go reports any error a tool handler returns as an
+// internal error without data, so the transports run Precheck on each raw
+// message before handing it to the server.
+
+// Precheck returns the error response for a tools/call message with invalid
+// arguments, or nil if the server should handle msg. The rejected call is
+// recorded in the audit log. ctx must carry what the call's own context would:
+// the caller's identity, transport and session.
+func Precheck(ctx context.Context, pm *process.Manager, msg []byte) *mcp.JSONRPCError {
+	var req struct {
+		ID     mcp.RequestId `json:"id"`
+		Method string        `json:"method"`
+		Params struct {
+			Name      string `json:"name"`
+			Arguments any    `json:"arguments"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(msg, &req); err != nil || req.Method != string(mcp.MethodToolsCall) || req.ID.IsNil() {
+		return nil
+	}
+	upstream, tool := splitName(req.Params.Name)
+	err := pm.PrecheckArguments(ctx, process.CallRequest{
+		Upstream:  upstream,
+		Tool:      tool,
+		Arguments: req.Params.Arguments,
+	})
+	var invalid schema.Errors
+	if !errors.As(err, &invalid) {
+		return nil
+	}
+	resp := mcp.NewJSONRPCError(req.ID, mcp.INVALID_PARAMS, err.Error(), map[string]any{"errors": invalid})
+	return &resp
+}
+
+// lockedWriter serializes writes, so responses Precheck writes do not
+// interleave with the server's. Both write a whole message per call.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+// precheckLines copies newline-delimited messages from in to the returned
+// reader, answering the ones Precheck rejects on out instead.
+func precheckLines(ctx context.Context, pm *process.Manager, in io.Reader, out io.Writer) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		r := bufio.NewReader(in)
+		for {
+			line, err := r.ReadBytes('\n')
+			if len(line) > 0 {
+				if resp := Precheck(ctx, pm, line); resp != nil {
+					b, _ := json.Marshal(resp)
+					_, _ = out.Write(append(b, '\n'))
+				} else if _, werr := pw.Write(line); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
@@ -0,0 +1,211 @@
+// Package policy evaluates the config rules that decide whether a tool call is
+// allowed, denied or sent to a human for approval.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gomcp-pilot/internal/config"
+)
+
+// Decision is the outcome of evaluating a call against the rules.
+type Decision struct {
+	Action string // config.ActionAllow, ActionDeny or ActionAsk
+	Rule   string // name of the matching rule
+	Grants bool   // saved grants may answer an ask decision
+}
+
+// Engine holds compiled rules. A nil *Engine matches nothing.
+type Engine struct {
+	rules []rule
+}
+
+type rule struct {
+	name     string
+	upstream string
+	tool     string
+	args     map[string]argMatcher
+	action   string
+	grants   bool
+}
+
+type argMatcher struct {
+	config.ArgMatch
+	regex *regexp.Regexp
+}
+
+// New compiles rules. Unnamed rules are referred to by position, e.g. "rules[2]".
+func New(rules []config.Rule) (*Engine, error) {
+	e := &Engine{}
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("rules[%d]", i)
+		}
+		for _, pattern := range []string{r.Upstream, r.Tool} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("rule %s: invalid pattern %q: %w", name, pattern, err)
+			}
+		}
+
+		compiled := rule{
+			name:     name,
+			upstream: r.Upstream,
+			tool:     r.Tool,
+			args:     make(map[string]argMatcher, len(r.Args)),
+			action:   r.Action,
+			grants:   r.Grants,
+		}
+		for arg, m := range r.Args {
+			am := argMatcher{ArgMatch: m}
+			if m.Regex != "" {
+				re, err := regexp.Compile(m.Regex)
+				if err != nil {
+					return nil, fmt.Errorf("rule %s: invalid regex for %s: %w", name, arg, err)
+				}
+				am.regex = re
+			}
+			compiled.args[arg] = am
+		}
+		e.rules = append(e.rules, compiled)
+	}
+	return e, nil
+}
+
+// Evaluate returns the decision of the first rule matching the call, and false
+// if no rule matches.
+func (e *Engine) Evaluate(upstream, tool string, args any) (Decision, bool) {
+	if e == nil {
+		return Decision{}, false
+	}
+	args = normalize(args)
+	for _, r := range e.rules {
+		if r.matches(upstream, tool, args) {
+			return Decision{Action: r.action, Rule: r.name, Grants: r.grants}, true
+		}
+	}
+	return Decision{}, false
+}
+
+func (r rule) matches(upstream, tool string, args any) bool {
+	if !glob(r.upstream, upstream) || !glob(r.tool, tool) {
+		return false
+	}
+	for name, m := range r.args {
+		v, ok := lookup(args, name)
+		if !ok || !m.matchesValue(v, r.action == config.ActionAllow) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesValue matches a single value directly. A list matches an allow rule
+// only if every element does, and any other rule if some element does, so a
+// list can neither smuggle a path past an allow rule nor slip around a deny
+// rule. Empty lists match nothing.
+func (m argMatcher) matchesValue(v any, allow bool) bool {
+	list, ok := v.([]any)
+	if !ok {
+		return m.matches(v)
+	}
+	if len(list) == 0 {
+		return false
+	}
+	for _, e := range list {
+		if m.matches(e) != allow {
+			return !allow
+		}
+	}
+	return allow
+}
+
+func (m argMatcher) matches(v any) bool {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case nil, map[string]any, []any:
+		return false
+	default:
+		// Numbers and booleans are compared by their printed form, and only
+		// by equals and regex: prefixes are for strings.
+		if m.Prefix != "" || m.PathPrefix != "" {
+			return false
+		}
+		s = fmt.Sprint(v)
+	}
+	if m.Equals != "" && s != m.Equals {
+		return false
+	}
+	if m.Prefix != "" && !strings.HasPrefix(s, m.Prefix) {
+		return false
+	}
+	if m.PathPrefix != "" && !underDir(s, m.PathPrefix) {
+		return false
+	}
+	if m.regex != nil && !m.regex.MatchString(s) {
+		return false
+	}
+	return true
+}
+
+func glob(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// underDir reports whether p, once cleaned, is dir or inside it, so that
+// "/etc/../etc/passwd" and "/etc" both match "/etc" but "/etcetera" does not.
+func underDir(p, dir string) bool {
+	p = filepath.Clean(p)
+	dir = filepath.Clean(dir)
+	if p == dir {
+		return true
+	}
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return strings.HasPrefix(p, dir)
+}
+
+// normalize converts typed arguments (structs, json.RawMessage) into the
+// generic form produced by decoding JSON, so lookup can walk them.
+func normalize(args any) any {
+	switch args.(type) {
+	case nil, map[string]any:
+		return args
+	}
+	b, err := json.Marshal(args)
+	if err != nil {
+		return args
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return args
+	}
+	return out
+}
+
+// lookup resolves a dotted argument name such as "options.path" in decoded JSON arguments.
+func lookup(args any, name string) (any, bool) {
+	cur := args
+	for _, part := range strings.Split(name, ".") {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
@@ -0,0 +1,145 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"gomcp-pilot/internal/config"
+)
+
+func mustNew(t *testing.T, rules ...config.Rule) *Engine {
+	t.Helper()
+	e, err := New(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestEvaluateArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		match config.ArgMatch
+		args  any
+		want  bool
+	}{
+		{"path under prefix", config.ArgMatch{PathPrefix: "/allowed"}, map[string]any{"path": "/allowed/a.txt"}, true},
+		{"path equal to prefix", config.ArgMatch{PathPrefix: "/allowed/"}, map[string]any{"path": "/allowed"}, true},
+		{"traversal out of prefix", config.ArgMatch{PathPrefix: "/allowed"}, map[string]any{"path": "/allowed/../etc"}, false},
+		{"traversal back into prefix", config.ArgMatch{PathPrefix: "/allowed"}, map[string]any{"path": "/tmp/../allowed/x"}, true},
+		{"sibling sharing a prefix", config.ArgMatch{PathPrefix: "/etc"}, map[string]any{"path": "/etcetera"}, false},
+		{"relative path", config.ArgMatch{PathPrefix: "/allowed"}, map[string]any{"path": "allowed/x"}, false},
+		{"plain prefix", config.ArgMatch{Prefix: "SELECT "}, map[string]any{"path": "SELECT 1"}, true},
+		{"equals", config.ArgMatch{Equals: "main"}, map[string]any{"path": "main"}, true},
+		{"equals mismatch", config.ArgMatch{Equals: "main"}, map[string]any{"path": "mainline"}, false},
+		{"regex", config.ArgMatch{Regex: `^[a-z]+$`}, map[string]any{"path": "abc"}, true},
+		{"every field must match", config.ArgMatch{Prefix: "/allowed", Regex: `\.txt$`}, map[string]any{"path": "/allowed/a.sh"}, false},
+		{"missing argument", config.ArgMatch{Equals: "x"}, map[string]any{"other": "x"}, false},
+		{"number equals", config.ArgMatch{Equals: "42"}, map[string]any{"path": float64(42)}, true},
+		{"bool regex", config.ArgMatch{Regex: `^true$`}, map[string]any{"path": true}, true},
+		{"number against prefix", config.ArgMatch{Prefix: "4"}, map[string]any{"path": float64(42)}, false},
+		{"null", config.ArgMatch{Regex: `.*`}, map[string]any{"path": nil}, false},
+		{"object", config.ArgMatch{Regex: `allowed`}, map[string]any{"path": map[string]any{"a": "/allowed"}}, false},
+		{"list all under prefix", config.ArgMatch{PathPrefix: "/allowed"}, map[string]any{"path": []any{"/allowed/a", "/allowed/b"}}, true},
+		{"list smuggling a path", config.ArgMatch{PathPrefix: "/allowed"}, map[string]any{"path": []any{"/allowed/a", "/etc/passwd"}}, false},
+		{"list against unanchored regex", config.ArgMatch{Regex: `/allowed/`}, map[string]any{"path": []any{"/allowed/a", "/etc/passwd"}}, false},
+		{"empty list", config.ArgMatch{Regex: `.*`}, map[string]any{"path": []any{}}, false},
+		{"typed arguments", config.ArgMatch{PathPrefix: "/allowed"}, struct {
+			Path string `json:"path"`
+		}{"/allowed/x"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := mustNew(t, config.Rule{Name: "r", Args: map[string]config.ArgMatch{"path": tt.match}, Action: config.ActionAllow})
+			_, got := e.Evaluate("fs", "read", tt.args)
+			if got != tt.want {
+				t.Errorf("Evaluate(%v) matched = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateListAgainstDeny(t *testing.T) {
+	e := mustNew(t,
+		config.Rule{Name: "no-etc", Args: map[string]config.ArgMatch{"paths": {PathPrefix: "/etc"}}, Action: config.ActionDeny},
+		config.Rule{Name: "rest", Action: config.ActionAllow},
+	)
+	tests := []struct {
+		paths []any
+		want  string
+	}{
+		{[]any{"/tmp/a", "/etc/passwd"}, "no-etc"},
+		{[]any{"/etc/../etc/shadow"}, "no-etc"},
+		{[]any{"/tmp/a", "/home/b"}, "rest"},
+		{[]any{}, "rest"},
+	}
+	for _, tt := range tests {
+		d, ok := e.Evaluate("fs", "read", map[string]any{"paths": tt.paths})
+		if !ok || d.Rule != tt.want {
+			t.Errorf("paths %v: rule = %q (matched %v), want %q", tt.paths, d.Rule, ok, tt.want)
+		}
+	}
+}
+
+func TestEvaluateOrderAndGlobs(t *testing.T) {
+	e := mustNew(t,
+		config.Rule{Name: "deny-writes", Upstream: "fs", Tool: "write_*", Action: config.ActionDeny},
+		config.Rule{Name: "ask-nested", Upstream: "fs", Args: map[string]config.ArgMatch{"options.recursive": {Equals: "true"}}, Action: config.ActionAsk},
+		config.Rule{Upstream: "fs", Action: config.ActionAllow},
+		config.Rule{Name: "never-reached", Upstream: "fs", Tool: "read", Action: config.ActionDeny},
+	)
+	tests := []struct {
+		upstream, tool string
+		args           any
+		want           Decision
+		matched        bool
+	}{
+		{"fs", "write_file", nil, Decision{Action: config.ActionDeny, Rule: "deny-writes"}, true},
+		{"fs", "list", map[string]any{"options": map[string]any{"recursive": true}}, Decision{Action: config.ActionAsk, Rule: "ask-nested"}, true},
+		{"fs", "list", map[string]any{"options": map[string]any{"recursive": false}}, Decision{Action: config.ActionAllow, Rule: "rules[2]"}, true},
+		{"fs", "read", nil, Decision{Action: config.ActionAllow, Rule: "rules[2]"}, true},
+		{"git", "read", nil, Decision{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := e.Evaluate(tt.upstream, tt.tool, tt.args)
+		if got != tt.want || ok != tt.matched {
+			t.Errorf("%s/%s: got %+v (%v), want %+v (%v)", tt.upstream, tt.tool, got, ok, tt.want, tt.matched)
+		}
+	}
+}
+
+func TestNewRejectsInvalidPatterns(t *testing.T) {
+	tests := []struct {
+		rule config.Rule
+		want string
+	}{
+		{config.Rule{Name: "bad-glob", Tool: "[", Action: config.ActionDeny}, "rule bad-glob: invalid pattern"},
+		{config.Rule{Args: map[string]config.ArgMatch{"q": {Regex: "("}}, Action: config.ActionDeny}, "rule rules[0]: invalid regex for q"},
+	}
+	for _, tt := range tests {
+		_, err := New([]config.Rule{tt.rule})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("New(%+v) error = %v, want %q", tt.rule, err, tt.want)
+		}
+	}
+}
+
+func TestNilEngine(t *testing.T) {
+	var e *Engine
+	if _, ok := e.Evaluate("fs", "read", nil); ok {
+		t.Error("nil engine matched a call")
+	}
+}
+
+func TestDecisionGrants(t *testing.T) {
+	e := mustNew(t,
+		config.Rule{Name: "ask-grantable", Tool: "write", Action: config.ActionAsk, Grants: true},
+		config.Rule{Name: "ask-always", Action: config.ActionAsk},
+	)
+	if d, _ := e.Evaluate("fs", "write", nil); !d.Grants {
+		t.Errorf("%s: Grants = false, want true", d.Rule)
+	}
+	if d, _ := e.Evaluate("fs", "delete", nil); d.Grants {
+		t.Errorf("%s: Grants = true, want false", d.Rule)
+	}
+}
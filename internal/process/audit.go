@@ -0,0 +1,270 @@
+package process
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/auth"
+	"gomcp-pilot/internal/logger"
+	"gomcp-pilot/internal/store"
+)
+
+// Transports a tool call can arrive over, as recorded in the audit log.
+const (
+	TransportREST           = "rest"
+	TransportSSE            = "sse"
+	TransportStreamableHTTP = "streamable-http"
+	TransportStdio          = "stdio"
+)
+
+// maxRecordedText bounds the text content of a result kept in the audit log.
+const maxRecordedText = 64 << 10
+
+type (
+	requestIDKey struct{}
+	transportKey struct{}
+	sessionIDKey struct{}
+)
+
+// WithRequestID returns a context whose tool calls use id as their request ID,
+// e.g. one the client sent. CallTool generates one otherwise.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID set by WithRequestID, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithTransport records which transport (TransportREST, ...) a call came over.
+func WithTransport(ctx context.Context, transport string) context.Context {
+	return context.WithValue(ctx, transportKey{}, transport)
+}
+
+// WithSessionID records the MCP session a call belongs to.
+func WithSessionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionIDKey{}, id)
+}
+
+// Operations recorded in the audit log, named after their MCP methods.
+const (
+	OpCallTool              = string(mcp.MethodToolsCall)
+	OpReadResource          = string(mcp.MethodResourcesRead)
+	OpGetPrompt             = string(mcp.MethodPromptsGet)
+	OpListTools             = string(mcp.MethodToolsList)
+	OpListResources         = string(mcp.MethodResourcesList)
+	OpListResourceTemplates = string(mcp.MethodResourcesTemplatesList)
+	OpListPrompts           = string(mcp.MethodPromptsList)
+)
+
+// ensureRequestID gives ctx a request ID if it does not carry one yet.
+func ensureRequestID(ctx context.Context) context.Context {
+	if RequestIDFromContext(ctx) == "" {
+		return WithRequestID(ctx, NewRequestID())
+	}
+	return ctx
+}
+
+// record completes rec with who made the operation, over which transport,
+// how long it took and how it ended, and writes it to the audit log. Every
+// audited operation goes through here.
+func record(ctx context.Context, rec store.CallRecord, duration time.Duration, err error) {
+	rec.Caller = auth.FromContext(ctx).Caller()
+	rec.Transport, _ = ctx.Value(transportKey{}).(string)
+	rec.SessionID, _ = ctx.Value(sessionIDKey{}).(string)
+	rec.RequestID = RequestIDFromContext(ctx)
+	rec.DurationMs = duration.Milliseconds()
+	rec.Status = store.StatusSuccess
+	if err != nil {
+		rec.Status = store.StatusError
+		switch {
+		case errors.Is(err, context.Canceled):
+			rec.Status = store.StatusCancelled
+		case errors.Is(err, context.DeadlineExceeded):
+			rec.Status = store.StatusTimeout
+		}
+		rec.Error = err.Error()
+	}
+	if err := store.RecordCall(rec); err != nil {
+		logger.Global.Warn("Failed to record operation",
+			zap.String("request_id", rec.RequestID),
+			zap.String("operation", rec.Operation),
+			zap.String("target", rec.Tool),
+			zap.Error(err))
+	}
+}
+
+// recordCall writes a finished tool call to the audit log.
+func recordCall(ctx context.Context, req CallRequest, res *mcp.CallToolResult, duration time.Duration, err error) {
+	args, _ := json.Marshal(req.Arguments)
+	rec := store.CallRecord{
+		Operation:         OpCallTool,
+		Upstream:          req.Upstream,
+		Tool:              req.Tool,
+		Arguments:         string(args),
+		Rule:              req.Approval.Rule,
+		ApprovedArguments: req.Approval.Arguments,
+		Decision:          req.Approval.Decision,
+		Approver:          req.Approval.Approver,
+	}
+	if req.Approval.Grant != 0 {
+		rec.Rule = fmt.Sprintf("grant:%d", req.Approval.Grant)
+	}
+	if res != nil {
+		r := newResultRecorder()
+		out := struct {
+			Content           []recordedContent `json:"content"`
+			StructuredContent json.RawMessage   `json:"structuredContent,omitempty"`
+			Truncated         bool              `json:"structuredContentTruncated,omitempty"`
+		}{Content: r.contents(res.Content)}
+		if res.StructuredContent != nil {
+			out.StructuredContent, out.Truncated = r.json(res.StructuredContent)
+		}
+		rec.Result = marshalResult(out)
+		rec.IsError = res.IsError
+	}
+	record(ctx, rec, duration, err)
+}
+
+// RecordList writes a listing a client made (OpListTools, ...) to the audit
+// log: the upstream it was limited to, if any, and how many entries the
+// client got back. Listings are served from the gateway's registrations
+// rather than through the Manager, so the transports report them here.
+func (m *Manager) RecordList(ctx context.Context, op, upstream string, count int, err error) {
+	rec := store.CallRecord{Operation: op, Upstream: upstream}
+	if err == nil {
+		rec.Result = marshalResult(map[string]int{"count": count})
+	}
+	record(ensureRequestID(ctx), rec, 0, err)
+}
+
+// recordedContent is a content item as kept in the audit log. Binary data is
+// replaced by its size and SHA-256; text past the budget is cut.
+type recordedContent struct {
+	Type      string `json:"type"`
+	Text      string `json:"text,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	MIMEType  string `json:"mimeType,omitempty"`
+	URI       string `json:"uri,omitempty"`
+	Size      int    `json:"size,omitempty"`
+	SHA256    string `json:"sha256,omitempty"`
+}
+
+// resultRecorder renders results for the audit log, keeping at most
+// maxRecordedText bytes of text across everything it renders.
+type resultRecorder struct {
+	budget int
+}
+
+func newResultRecorder() *resultRecorder {
+	return &resultRecorder{budget: maxRecordedText}
+}
+
+func (r *resultRecorder) text(s string) (string, bool) {
+	if len(s) <= r.budget {
+		r.budget -= len(s)
+		return s, false
+	}
+	// Cut on a rune boundary so the text stays valid UTF-8.
+	n := r.budget
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	r.budget = 0
+	return s[:n], true
+}
+
+func (r *resultRecorder) binary(c *recordedContent, data string) {
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		b = []byte(data)
+	}
+	sum := sha256.Sum256(b)
+	c.Size, c.SHA256 = len(b), hex.EncodeToString(sum[:])
+}
+
+// json marshals v if it fits the remaining budget, and reports whether it was
+// dropped for not fitting.
+func (r *resultRecorder) json(v any) (json.RawMessage, bool) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	if len(b) > r.budget {
+		return nil, true
+	}
+	r.budget -= len(b)
+	return b, false
+}
+
+func (r *resultRecorder) contents(content []mcp.Content) []recordedContent {
+	out := make([]recordedContent, 0, len(content))
+	for _, c := range content {
+		out = append(out, r.content(c))
+	}
+	return out
+}
+
+func (r *resultRecorder) content(c mcp.Content) recordedContent {
+	var rc recordedContent
+	switch c := c.(type) {
+	case mcp.TextContent:
+		rc.Type = c.Type
+		rc.Text, rc.Truncated = r.text(c.Text)
+	case mcp.ImageContent:
+		rc.Type, rc.MIMEType = c.Type, c.MIMEType
+		r.binary(&rc, c.Data)
+	case mcp.AudioContent:
+		rc.Type, rc.MIMEType = c.Type, c.MIMEType
+		r.binary(&rc, c.Data)
+	case mcp.ResourceLink:
+		rc.Type, rc.MIMEType, rc.URI = c.Type, c.MIMEType, c.URI
+	case mcp.EmbeddedResource:
+		rc = r.resource(c.Resource)
+		rc.Type = c.Type
+	default:
+		rc.Type = "unknown"
+	}
+	return rc
+}
+
+// resource renders resource contents, as read or embedded in a result.
+func (r *resultRecorder) resource(c mcp.ResourceContents) recordedContent {
+	var rc recordedContent
+	switch c := c.(type) {
+	case mcp.TextResourceContents:
+		rc.Type, rc.MIMEType, rc.URI = "text", c.MIMEType, c.URI
+		rc.Text, rc.Truncated = r.text(c.Text)
+	case mcp.BlobResourceContents:
+		rc.Type, rc.MIMEType, rc.URI = "blob", c.MIMEType, c.URI
+		r.binary(&rc, c.Blob)
+	default:
+		rc.Type = "unknown"
+	}
+	return rc
+}
+
+func marshalResult(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,105 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/store"
+)
+
+// openStore gives the test an in-memory audit store.
+func openStore(t *testing.T) {
+	t.Helper()
+	if err := store.InitStore(store.Memory); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		store.Close()
+		store.DB = nil
+	})
+}
+
+// lastCall returns the newest record in the audit log.
+func lastCall(t *testing.T) store.CallRecord {
+	t.Helper()
+	calls, _, err := store.QueryCalls(store.CallFilter{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) == 0 {
+		t.Fatal("nothing recorded")
+	}
+	return calls[0]
+}
+
+func TestRecordStatus(t *testing.T) {
+	openStore(t)
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, store.StatusSuccess},
+		{errors.New("boom"), store.StatusError},
+		{context.Canceled, store.StatusCancelled},
+		{fmt.Errorf("fs/read: %w", context.Canceled), store.StatusCancelled},
+		{fmt.Errorf("fs/read timed out after 1s: %w", context.DeadlineExceeded), store.StatusTimeout},
+	}
+	for _, tt := range tests {
+		ctx := WithTransport(WithRequestID(context.Background(), "req-1"), TransportREST)
+		record(ctx, store.CallRecord{Operation: OpCallTool, Upstream: "fs", Tool: "read"}, 1500*time.Millisecond, tt.err)
+		r := lastCall(t)
+		if r.Status != tt.want || r.DurationMs != 1500 || r.RequestID != "req-1" || r.Transport != TransportREST {
+			t.Errorf("%v: recorded %+v, want status %s", tt.err, r, tt.want)
+		}
+		if tt.err != nil && r.Error != tt.err.Error() {
+			t.Errorf("%v: error %q", tt.err, r.Error)
+		}
+	}
+}
+
+func TestCallTimeoutIsRecorded(t *testing.T) {
+	openStore(t)
+	srv := toolServer("fast")
+	cancelled := make(chan mcp.NotificationParams, 1)
+	srv.AddTool(mcp.NewTool("slow"), func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	srv.AddNotificationHandler(methodCancelled, func(_ context.Context, n mcp.JSONRPCNotification) {
+		cancelled <- n.Params
+	})
+	ups := serveUpstream(t, "fs", srv)
+	ups.AutoApprove = true
+	ups.CallTimeout = 5 * time.Second
+	ups.ToolTimeouts = map[string]time.Duration{"slow": 50 * time.Millisecond}
+	m := startManager(t, &config.Config{Upstreams: []config.Upstream{ups}})
+
+	if _, err := m.CallTool(context.Background(), CallRequest{Upstream: "fs", Tool: "fast"}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err := m.CallTool(context.Background(), CallRequest{Upstream: "fs", Tool: "slow"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want a timeout", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("call took %s despite a 50ms tool timeout", d)
+	}
+	if r := lastCall(t); r.Tool != "slow" || r.Status != store.StatusTimeout {
+		t.Errorf("recorded %+v, want a timeout", r)
+	}
+	select {
+	case p := <-cancelled:
+		if reason := p.AdditionalFields["reason"]; reason != "request timed out" {
+			t.Errorf("upstream told %v", reason)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("upstream was not told the call timed out")
+	}
+}
@@ -0,0 +1,81 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/logger"
+)
+
+const (
+	methodCancelled     = "notifications/cancelled"
+	cancelNotifyTimeout = 5 * time.Second
+)
+
+// cancellingTransport wraps an upstream transport so that a request abandoned
+// because its context ended is reported to the upstream with
+// notifications/cancelled, letting the server stop work nobody is waiting for.
+type cancellingTransport struct {
+	transport.Interface
+}
+
+func (t *cancellingTransport) SendRequest(ctx context.Context, req transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	resp, err := t.Interface.SendRequest(ctx, req)
+	// The spec forbids cancelling initialize.
+	if err != nil && ctx.Err() != nil && req.Method != string(mcp.MethodInitialize) {
+		t.sendCancelled(req, ctx.Err())
+	}
+	return resp, err
+}
+
+func (t *cancellingTransport) sendCancelled(req transport.JSONRPCRequest, cause error) {
+	reason := "request cancelled by client"
+	if errors.Is(cause, context.DeadlineExceeded) {
+		reason = "request timed out"
+	}
+	n := mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: methodCancelled,
+			Params: mcp.NotificationParams{
+				AdditionalFields: map[string]any{
+					"requestId": req.ID,
+					"reason":    reason,
+				},
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cancelNotifyTimeout)
+	defer cancel()
+	if err := t.Interface.SendNotification(ctx, n); err != nil {
+		logger.Global.Warn("Failed to send cancellation upstream",
+			zap.String("method", req.Method),
+			zap.Error(err))
+	}
+}
+
+// The client discovers optional transport features by type assertion, so the
+// wrapper forwards them to the underlying transport.
+
+func (t *cancellingTransport) SetRequestHandler(handler transport.RequestHandler) {
+	if b, ok := t.Interface.(transport.BidirectionalInterface); ok {
+		b.SetRequestHandler(handler)
+	}
+}
+
+func (t *cancellingTransport) SetProtocolVersion(version string) {
+	if h, ok := t.Interface.(transport.HTTPConnection); ok {
+		h.SetProtocolVersion(version)
+	}
+}
+
+func (t *cancellingTransport) SetConnectionLostHandler(handler func(error)) {
+	if s, ok := t.Interface.(interface{ SetConnectionLostHandler(func(error)) }); ok {
+		s.SetConnectionLostHandler(handler)
+	}
+}
@@ -3,8 +3,11 @@ package process
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,8 +16,12 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"go.uber.org/zap"
 
+	"gomcp-pilot/internal/auth"
 	"gomcp-pilot/internal/config"
 	"gomcp-pilot/internal/logger"
+	"gomcp-pilot/internal/policy"
+	"gomcp-pilot/internal/schema"
+	"gomcp-pilot/internal/store"
 )
 
 // CallRequest represents a tool invocation against a specific upstream.
@@ -22,8 +29,39 @@ type CallRequest struct {
 	Upstream  string
 	Tool      string
 	Arguments any
+	// OnProgress, if set, receives progress notifications the upstream emits for this call.
+	OnProgress ProgressFunc
+	// Approval, if set, is filled in with how the call was authorized.
+	Approval *Approval
 }
 
+// Approval records which policy rule or standing grant, if any, decided a tool call.
+type Approval struct {
+	Rule   string // name of the matching rule; empty when auto_approve or the interceptor decided
+	Action string // config.ActionAllow, ActionDeny or ActionAsk
+	Grant  int64  // ID of the persisted grant that approved the call without asking
+	// Arguments holds the JSON arguments the call was made with when the
+	// reviewer edited them before approving; empty if they were unchanged.
+	Arguments string
+	Decision  string // DecisionApproved or DecisionDenied; empty if none was reached
+	// Approver names who or what decided: ApproverPolicy, ApproverAutoApprove,
+	// ApproverGrant, or the Verdict's Approver when the interceptor decided.
+	Approver string
+}
+
+// Values of Approval.Decision.
+const (
+	DecisionApproved = "approved"
+	DecisionDenied   = "denied"
+)
+
+// Approvers of calls the gateway decides without the interceptor.
+const (
+	ApproverPolicy      = "policy"       // a rule with action allow or deny
+	ApproverAutoApprove = "auto_approve" // the upstream's auto_approve, no rule matched
+	ApproverGrant       = "grant"        // a standing grant
+)
+
 // ToolDescriptor is returned to HTTP clients when listing tools.
 type ToolDescriptor struct {
 	Upstream    string `json:"upstream"`
@@ -35,30 +73,83 @@ type ToolDescriptor struct {
 
 // Manager owns the lifecycle of all upstream MCP clients.
 type Manager struct {
-	mu          sync.RWMutex
-	upstreams   map[string]*upstreamClient
-	interceptor func(upstream, tool, args string) bool // Returns true if allowed
+	mu            sync.RWMutex
+	upstreams     map[string]*upstreamClient
+	resourceIndex map[string]string // resource URI -> owning upstream
+	interceptor   Interceptor
+	notifier      func(upstream string, n mcp.JSONRPCNotification)
+	policy        *policy.Engine
+
+	progressMu sync.Mutex
+	progress   map[string]ProgressFunc // gateway progress token -> downstream callback
 }
 
 type upstreamClient struct {
-	cfg    config.Upstream
-	client *client.Client
-	tools  []mcp.Tool
+	cfg     config.Upstream
+	client  *client.Client
+	tools   []mcp.Tool
+	prompts []mcp.Prompt
+
+	// Resource state, guarded by Manager.mu.
+	hasResources bool
+	resources    []mcp.Resource
+	templates    []mcp.ResourceTemplate
+
+	// Supervisor state, guarded by Manager.mu.
+	state       State
+	restarts    int
+	lastError   string
+	lastRestart time.Time
+	closeConn   context.CancelFunc // kills the current child process
+	stop        context.CancelFunc // stops the supervisor goroutine
+	kick        chan struct{}      // requests an immediate health check
+	exited      <-chan struct{}    // closed when the current stdio child exits; nil for remote transports
 }
 
 // NewManager builds an empty manager. Call StartAll before serving traffic.
 func NewManager() *Manager {
 	return &Manager{
-		upstreams: make(map[string]*upstreamClient),
+		upstreams:     make(map[string]*upstreamClient),
+		resourceIndex: make(map[string]string),
+		progress:      make(map[string]ProgressFunc),
 	}
 }
 
-func (m *Manager) SetInterceptor(fn func(upstream, tool, args string) bool) {
+// Interceptor decides whether a tool call on a non-auto-approved upstream may
+// proceed. ctx ends when the caller gives up, so implementations waiting on a
+// human should stop waiting and deny. GrantsAllowed(ctx) tells whether a
+// standing grant may answer the call.
+type Interceptor func(ctx context.Context, upstream, tool, args string) Verdict
+
+type grantsKey struct{}
+
+// GrantsAllowed reports whether standing grants may answer the call an
+// Interceptor was given ctx for: it matched no policy rule, or an ask rule
+// with grants: true.
+func GrantsAllowed(ctx context.Context) bool {
+	ok, _ := ctx.Value(grantsKey{}).(bool)
+	return ok
+}
+
+// Verdict is an Interceptor's answer.
+type Verdict struct {
+	Approved bool
+	// Arguments are the JSON arguments to call the tool with: the args the
+	// interceptor got, or a version the reviewer edited. Empty means unchanged.
+	Arguments string
+	// Approver names who decided, for the audit log.
+	Approver string
+}
+
+func (m *Manager) SetInterceptor(fn Interceptor) {
 	m.interceptor = fn
 }
 
 // StartAll spawns and initializes every configured upstream.
 func (m *Manager) StartAll(ctx context.Context, cfg *config.Config) error {
+	if err := m.loadPolicy(cfg); err != nil {
+		return err
+	}
 	for _, ups := range cfg.Upstreams {
 		if err := m.startOne(ctx, ups); err != nil {
 			return err
@@ -67,62 +158,105 @@ func (m *Manager) StartAll(ctx context.Context, cfg *config.Config) error {
 	return nil
 }
 
-// StopAll tears down every upstream client.
-func (m *Manager) StopAll() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	for name, ups := range m.upstreams {
-		_ = ups.client.Close()
-		delete(m.upstreams, name)
+// loadPolicy compiles and installs the approval rules from cfg.
+func (m *Manager) loadPolicy(cfg *config.Config) error {
+	engine, err := policy.New(cfg.Rules)
+	if err != nil {
+		return fmt.Errorf("load rules: %w", err)
 	}
+	m.mu.Lock()
+	m.policy = engine
+	m.mu.Unlock()
+	return nil
 }
 
-// ResourceDescriptor represents an available resource from an upstream.
-type ResourceDescriptor struct {
-	Upstream    string `json:"upstream"`
-	Name        string `json:"name"`
-	Uri         string `json:"uri"`
-	MimeType    string `json:"mimeType,omitempty"`
-	Description string `json:"description,omitempty"`
-}
+// Reload reconciles running upstreams with cfg: new upstreams are started,
+// removed ones are stopped and ones whose configuration changed are replaced
+// once the new instance is up. If it fails to start, the old instance keeps
+// running with its old configuration. Failures to start individual upstreams
+// are collected and returned together.
+func (m *Manager) Reload(ctx context.Context, cfg *config.Config) error {
+	if err := m.loadPolicy(cfg); err != nil {
+		return err
+	}
 
-// ListResources aggregates resources across upstreams.
-func (m *Manager) ListResources(upstreamFilter string) ([]ResourceDescriptor, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	wanted := make(map[string]config.Upstream, len(cfg.Upstreams))
+	for _, ups := range cfg.Upstreams {
+		wanted[ups.Name] = ups
+	}
 
-	var result []ResourceDescriptor
-	for name, ups := range m.upstreams {
-		if upstreamFilter != "" && name != upstreamFilter {
-			continue
+	m.mu.RLock()
+	var removed []string
+	for name := range m.upstreams {
+		if _, ok := wanted[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	var fresh []config.Upstream
+	for _, ups := range cfg.Upstreams {
+		if cur, ok := m.upstreams[ups.Name]; !ok || !reflect.DeepEqual(cur.cfg, ups) {
+			fresh = append(fresh, ups)
 		}
+	}
+	m.mu.RUnlock()
 
-		// Resources are fetched dynamically or cached?
-		// mcp-go client doesn't seem to cache resources automatically like tools in some examples.
-		// We should fetch them on demand.
+	for _, name := range removed {
+		logger.Global.Info("Stopping upstream for reload", zap.String("upstream", name))
+		m.stopOne(name)
+	}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		res, err := ups.client.ListResources(ctx, mcp.ListResourcesRequest{})
-		cancel()
-		if err != nil {
-			// Log error but continue with other upstreams? Or fail?
-			// For now, let's log and continue
-			logger.Global.Warn("Failed to list resources", zap.String("upstream", name), zap.Error(err))
-			continue
+	var errs []error
+	for _, ups := range fresh {
+		logger.Global.Info("Starting upstream for reload", zap.String("upstream", ups.Name))
+		if err := m.startOne(ctx, ups); err != nil {
+			logger.Global.Error("Failed to start upstream", zap.String("upstream", ups.Name), zap.Error(err))
+			errs = append(errs, err)
 		}
+	}
+	return errors.Join(errs...)
+}
 
-		for _, r := range res.Resources {
-			result = append(result, ResourceDescriptor{
-				Upstream:    name,
-				Name:        r.Name,
-				Uri:         r.URI,
-				MimeType:    r.MIMEType,
-				Description: r.Description,
-			})
-		}
+// stopOne tears down a single upstream and its supervisor.
+func (m *Manager) stopOne(name string) {
+	m.mu.Lock()
+	ups := m.upstreams[name]
+	delete(m.upstreams, name)
+	if ups != nil {
+		m.unindexResources(ups)
+	}
+	m.mu.Unlock()
+	if ups != nil {
+		m.shutdown(ups)
 	}
+}
 
-	return result, nil
+// StopAll tears down every upstream client.
+func (m *Manager) StopAll() {
+	m.mu.Lock()
+	stopping := make([]*upstreamClient, 0, len(m.upstreams))
+	for name, ups := range m.upstreams {
+		stopping = append(stopping, ups)
+		m.unindexResources(ups)
+		delete(m.upstreams, name)
+	}
+	m.mu.Unlock()
+
+	for _, ups := range stopping {
+		m.shutdown(ups)
+	}
+}
+
+// shutdown closes the client gracefully, then kills the child and stops the supervisor.
+// The upstream must already be removed from m.upstreams so a concurrent restart discards
+// its new connection instead of installing it.
+func (m *Manager) shutdown(ups *upstreamClient) {
+	m.mu.RLock()
+	cl, closeConn := ups.client, ups.closeConn
+	m.mu.RUnlock()
+
+	_ = cl.Close()
+	closeConn()
+	ups.stop()
 }
 
 // ListTools aggregates tools across upstreams. If upstreamFilter is non-empty, only
@@ -146,27 +280,173 @@ func (m *Manager) ListTools(upstreamFilter string) ([]ToolDescriptor, error) {
 				Name:        t.Name,
 				Title:       title,
 				Description: t.Description,
-				InputSchema: t.InputSchema,
+				InputSchema: inputSchema(t),
 			})
 		}
 	}
-	if upstreamFilter != "" && len(result) == 0 {
+	if upstreamFilter != "" && m.upstreams[upstreamFilter] == nil {
 		return nil, fmt.Errorf("upstream %s not found", upstreamFilter)
 	}
 	return result, nil
 }
 
-// CallTool forwards a tool invocation to the specified upstream.
-func (m *Manager) CallTool(ctx context.Context, req CallRequest) (*mcp.CallToolResult, error) {
+// ErrToolNotFound is returned for a tool its upstream did not list.
+var ErrToolNotFound = errors.New("tool not found")
+
+// ValidateArguments checks args against the inputSchema the upstream advertises
+// for tool. Missing arguments are validated as an empty object. Errors listing
+// the failing fields are of type schema.Errors.
+func (m *Manager) ValidateArguments(upstream, tool string, args any) error {
+	m.mu.RLock()
+	ups := m.upstreams[upstream]
+	var def *mcp.Tool
+	if ups != nil {
+		for i := range ups.tools {
+			if ups.tools[i].Name == tool {
+				def = &ups.tools[i]
+				break
+			}
+		}
+	}
+	m.mu.RUnlock()
+	if ups == nil {
+		return fmt.Errorf("upstream %s not found", upstream)
+	}
+	if def == nil {
+		return fmt.Errorf("%w: %s/%s", ErrToolNotFound, upstream, tool)
+	}
+
+	if args == nil {
+		args = map[string]any{}
+	}
+	return schema.Validate(inputSchema(*def), args)
+}
+
+// ValidateEdited checks arguments a reviewer edited before approving a call,
+// under the upstream's schema_validation mode like the call itself: only
+// enforce rejects arguments that do not match the schema. A tool the gateway
+// does not know is an error in every mode.
+func (m *Manager) ValidateEdited(upstream, tool, args string) error {
+	m.mu.RLock()
+	ups := m.upstreams[upstream]
+	m.mu.RUnlock()
+	if ups == nil {
+		return fmt.Errorf("upstream %s not found", upstream)
+	}
+	err := m.ValidateArguments(upstream, tool, args)
+	if err == nil || errors.Is(err, ErrToolNotFound) {
+		return err
+	}
+	var invalid schema.Errors
+	mode := ups.cfg.SchemaValidation
+	if !errors.As(err, &invalid) || mode == config.ValidationOff {
+		return nil
+	}
+	if mode == config.ValidationEnforce {
+		return err
+	}
+	logger.Global.Warn("Edited arguments do not match the input schema",
+		zap.String("upstream", upstream),
+		zap.String("tool", tool),
+		zap.Error(invalid))
+	return nil
+}
+
+// checkArguments applies the upstream's schema_validation mode to a call. In
+// enforce mode invalid arguments are rejected with an error wrapping
+// schema.Errors; a tool the gateway has no schema for is let through.
+func (m *Manager) checkArguments(log *zap.Logger, cfg config.Upstream, req CallRequest) error {
+	if cfg.SchemaValidation == config.ValidationOff {
+		return nil
+	}
+	err := m.ValidateArguments(req.Upstream, req.Tool, req.Arguments)
+	var invalid schema.Errors
+	if !errors.As(err, &invalid) {
+		switch {
+		case errors.Is(err, ErrToolNotFound):
+			log.Debug("Skipping argument validation",
+				zap.String("upstream", req.Upstream),
+				zap.String("tool", req.Tool),
+				zap.Error(err))
+		case err != nil:
+			log.Warn("Cannot validate arguments against the tool's input schema",
+				zap.String("upstream", req.Upstream),
+				zap.String("tool", req.Tool),
+				zap.Error(err))
+		}
+		return nil
+	}
+
+	log.Warn("Tool call arguments do not match the input schema",
+		zap.String("upstream", req.Upstream),
+		zap.String("tool", req.Tool),
+		zap.String("mode", cfg.SchemaValidation),
+		zap.Error(invalid))
+	if cfg.SchemaValidation == config.ValidationEnforce {
+		return fmt.Errorf("%s/%s: %w", req.Upstream, req.Tool, invalid)
+	}
+	return nil
+}
+
+// PrecheckArguments returns the error CallTool would reject req with because
+// its arguments fail the tool's schema under schema_validation: enforce, and
+// records the rejected call in the audit log as CallTool would. Transports
+// that report invalid arguments as a protocol error call it first; for any
+// other outcome it returns nil and leaves the call to CallTool.
+func (m *Manager) PrecheckArguments(ctx context.Context, req CallRequest) error {
 	m.mu.RLock()
 	ups := m.upstreams[req.Upstream]
 	m.mu.RUnlock()
-	logger.Global.Info("Processing tool call",
+	if ups == nil || ups.cfg.SchemaValidation != config.ValidationEnforce {
+		return nil
+	}
+	if id := auth.FromContext(ctx); !id.Can(config.ScopeCall) || !id.AllowsTool(req.Upstream, req.Tool) {
+		return nil
+	}
+	ctx = ensureRequestID(ctx)
+	log := logger.Global.With(zap.String("request_id", RequestIDFromContext(ctx)))
+	err := m.checkArguments(log, ups.cfg, req)
+	if err != nil {
+		req.Approval = &Approval{}
+		recordCall(ctx, req, nil, 0, err)
+	}
+	return err
+}
+
+// CallTool forwards a tool invocation to the specified upstream and records
+// it in the audit log. Log lines about the call carry its request ID: the one
+// in ctx (see WithRequestID), or a new one.
+func (m *Manager) CallTool(ctx context.Context, req CallRequest) (*mcp.CallToolResult, error) {
+	if req.Approval == nil {
+		req.Approval = &Approval{}
+	}
+	ctx = ensureRequestID(ctx)
+	start := time.Now()
+	res, err := m.callTool(ctx, req)
+	recordCall(ctx, req, res, time.Since(start), err)
+	return res, err
+}
+
+func (m *Manager) callTool(ctx context.Context, req CallRequest) (*mcp.CallToolResult, error) {
+	log := logger.Global.With(zap.String("request_id", RequestIDFromContext(ctx)))
+	log.Info("Processing tool call",
 		zap.String("upstream", req.Upstream),
 		zap.String("tool", req.Tool))
 
-	if ups == nil {
-		return nil, fmt.Errorf("upstream %s not found", req.Upstream)
+	if id := auth.FromContext(ctx); !id.Can(config.ScopeCall) || !id.AllowsTool(req.Upstream, req.Tool) {
+		log.Warn("Tool call refused for token",
+			zap.String("token", id.Caller()),
+			zap.String("upstream", req.Upstream),
+			zap.String("tool", req.Tool))
+		return nil, fmt.Errorf("%w: token %s may not call %s/%s", auth.ErrForbidden, id.Caller(), req.Upstream, req.Tool)
+	}
+
+	ups, cl, err := m.clientFor(req.Upstream)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkArguments(log, ups.cfg, req); err != nil {
+		return nil, err
 	}
 
 	callReq := mcp.CallToolRequest{
@@ -176,32 +456,124 @@ func (m *Manager) CallTool(ctx context.Context, req CallRequest) (*mcp.CallToolR
 			Arguments: req.Arguments,
 		},
 	}
-	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
-	defer cancel()
-
+	if req.OnProgress != nil {
+		token, release := m.registerProgress(req.OnProgress)
+		defer release()
+		callReq.Params.Meta = &mcp.Meta{ProgressToken: token}
+	}
 	// Interception Logic
 	argBytes, _ := json.Marshal(req.Arguments)
 	argStr := string(argBytes)
 
-	if !ups.cfg.AutoApprove && m.interceptor != nil {
-		if !m.interceptor(req.Upstream, req.Tool, argStr) {
-			logger.Global.Warn("Tool call intercepted and denied",
+	// Policy rules come first; calls no rule matches fall back to auto_approve.
+	m.mu.RLock()
+	engine := m.policy
+	m.mu.RUnlock()
+	decision, matched := engine.Evaluate(req.Upstream, req.Tool, req.Arguments)
+	approver := ApproverPolicy
+	if !matched {
+		decision.Action = config.ActionAsk
+		if ups.cfg.AutoApprove {
+			decision.Action = config.ActionAllow
+			approver = ApproverAutoApprove
+		}
+	}
+	if req.Approval != nil {
+		*req.Approval = Approval{Rule: decision.Rule, Action: decision.Action}
+	}
+	if decision.Action == config.ActionDeny {
+		log.Warn("Tool call denied by policy",
+			zap.String("upstream", req.Upstream),
+			zap.String("tool", req.Tool),
+			zap.String("rule", decision.Rule))
+		req.Approval.deny(approver)
+		return nil, fmt.Errorf("operation denied by policy rule %s", decision.Rule)
+	}
+	if decision.Rule != "" {
+		log.Info("Tool call matched policy rule",
+			zap.String("upstream", req.Upstream),
+			zap.String("tool", req.Tool),
+			zap.String("rule", decision.Rule),
+			zap.String("action", decision.Action))
+	}
+
+	// A standing grant ("always", "for 1 hour", "identical arguments") answers
+	// in place of a human, unless an ask rule matched without opting in.
+	grantable := !matched || decision.Grants
+	if decision.Action == config.ActionAsk && grantable {
+		grant, err := store.MatchGrant(req.Upstream, req.Tool, argStr)
+		if err != nil && !errors.Is(err, store.ErrNoStore) {
+			// Without a store there are no grants to find.
+			log.Warn("Failed to look up approval grants", zap.Error(err))
+		}
+		if grant != 0 {
+			log.Info("Tool call approved by grant",
 				zap.String("upstream", req.Upstream),
-				zap.String("tool", req.Tool))
+				zap.String("tool", req.Tool),
+				zap.Int64("grant", grant))
+			decision.Action = config.ActionAllow
+			approver = ApproverGrant
+			if req.Approval != nil {
+				req.Approval.Grant = grant
+			}
+		}
+	}
+	if decision.Action == config.ActionAllow {
+		req.Approval.approve(approver)
+	}
+
+	if decision.Action == config.ActionAsk && m.interceptor != nil {
+		verdict := m.interceptor(context.WithValue(ctx, grantsKey{}, grantable), req.Upstream, req.Tool, argStr)
+		if !verdict.Approved {
+			if err := ctx.Err(); err != nil {
+				log.Warn("Tool call cancelled while awaiting approval",
+					zap.String("upstream", req.Upstream),
+					zap.String("tool", req.Tool))
+				return nil, err
+			}
+			log.Warn("Tool call intercepted and denied",
+				zap.String("upstream", req.Upstream),
+				zap.String("tool", req.Tool),
+				zap.String("approver", verdict.Approver))
+			req.Approval.deny(verdict.Approver)
 			return nil, fmt.Errorf("operation denied by user")
 		}
+		req.Approval.approve(verdict.Approver)
+		if verdict.Arguments != "" && verdict.Arguments != argStr {
+			if err := m.useEditedArguments(log, engine, req, &callReq, verdict.Arguments); err != nil {
+				return nil, err
+			}
+			argStr = verdict.Arguments
+		}
 	}
 
-	logger.Global.Info(fmt.Sprintf(">> Calling MCP: %s/%s %s", req.Upstream, req.Tool, argStr))
+	// The timeout covers the upstream call only, not the time spent awaiting approval.
+	timeout := ups.cfg.TimeoutFor(req.Tool)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	log.Info(fmt.Sprintf(">> Calling MCP: %s/%s %s", req.Upstream, req.Tool, argStr))
 
 	start := time.Now()
-	res, err := ups.client.CallTool(ctx, callReq)
+	res, err := cl.CallTool(ctx, callReq)
 	duration := time.Since(start)
 
 	if err != nil {
-		logger.Global.Error(fmt.Sprintf("<< Error: %v", err),
+		if errors.Is(err, context.Canceled) {
+			log.Warn("<< Cancelled by client",
+				zap.String("upstream", req.Upstream),
+				zap.String("tool", req.Tool),
+				zap.Duration("duration", duration))
+			return nil, err
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%s/%s timed out after %s: %w", req.Upstream, req.Tool, timeout, err)
+		}
+		log.Error(fmt.Sprintf("<< Error: %v", err),
 			zap.String("upstream", req.Upstream),
 			zap.String("tool", req.Tool))
+		// A transport failure usually means the child died; let the supervisor check now.
+		ups.requestCheck()
 		return nil, err
 	}
 
@@ -221,7 +593,7 @@ func (m *Manager) CallTool(ctx context.Context, req CallRequest) (*mcp.CallToolR
 		resStr = "[No Content]"
 	}
 
-	logger.Global.Info(fmt.Sprintf("<< Result: %s", resStr),
+	log.Info(fmt.Sprintf("<< Result: %s", resStr),
 		zap.String("upstream", req.Upstream),
 		zap.String("tool", req.Tool),
 		zap.Duration("duration", duration))
@@ -230,60 +602,134 @@ func (m *Manager) CallTool(ctx context.Context, req CallRequest) (*mcp.CallToolR
 
 }
 
-// ReadResource attempts to read a resource from any upstream that has it.
-func (m *Manager) ReadResource(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+func (a *Approval) approve(approver string) {
+	if a != nil {
+		a.Decision, a.Approver = DecisionApproved, approver
+	}
+}
+
+func (a *Approval) deny(approver string) {
+	if a != nil {
+		a.Decision, a.Approver = DecisionDenied, approver
+	}
+}
+
+// useEditedArguments replaces the arguments of callReq with the ones a reviewer
+// approved. Deny rules still apply to the edited call.
+func (m *Manager) useEditedArguments(log *zap.Logger, engine *policy.Engine, req CallRequest, callReq *mcp.CallToolRequest, args string) error {
+	var edited any
+	if err := json.Unmarshal([]byte(args), &edited); err != nil {
+		return fmt.Errorf("decode approved arguments: %w", err)
+	}
+	if d, ok := engine.Evaluate(req.Upstream, req.Tool, edited); ok && d.Action == config.ActionDeny {
+		log.Warn("Edited tool call denied by policy",
+			zap.String("upstream", req.Upstream),
+			zap.String("tool", req.Tool),
+			zap.String("rule", d.Rule))
+		req.Approval.deny(ApproverPolicy)
+		return fmt.Errorf("operation denied by policy rule %s", d.Rule)
+	}
+	log.Info("Tool call approved with edited arguments",
+		zap.String("upstream", req.Upstream),
+		zap.String("tool", req.Tool),
+		zap.String("arguments", args))
+	callReq.Params.Arguments = edited
+	if req.Approval != nil {
+		req.Approval.Arguments = args
+	}
+	return nil
+}
+
+// clientFor returns the named upstream and its current client, or an error if the
+// upstream is unknown or not running.
+func (m *Manager) clientFor(upstream string) (*upstreamClient, *client.Client, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	// Naive approach: try all upstreams.
-	// Optimization: This could be slow. Future work: cache URI->Upstream mapping.
-	for name, ups := range m.upstreams {
-		req := mcp.ReadResourceRequest{
-			Request: mcp.Request{Method: string(mcp.MethodResourcesRead)},
-			Params: mcp.ReadResourceParams{
-				URI: uri,
-			},
-		}
+	ups := m.upstreams[upstream]
+	if ups == nil {
+		return nil, nil, fmt.Errorf("upstream %s not found", upstream)
+	}
+	if ups.state != StateRunning {
+		return nil, nil, fmt.Errorf("upstream %s is %s", upstream, strings.ToLower(string(ups.state)))
+	}
+	return ups, ups.client, nil
+}
 
-		// Set a short timeout for the check/read
-		readCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-		res, err := ups.client.ReadResource(readCtx, req)
-		cancel()
+// startOne connects to ups and starts supervising it. A running instance of
+// the same name is replaced, and stopped, only once the new one is up.
+func (m *Manager) startOne(ctx context.Context, ups config.Upstream) error {
+	supCtx, stop := context.WithCancel(ctx)
+	conn, err := m.connect(supCtx, ups)
+	if err != nil {
+		stop()
+		return err
+	}
 
-		if err == nil {
-			logger.Global.Info("ReadResource success", zap.String("upstream", name), zap.String("uri", uri))
-			return res, nil
-		}
-		// If error is "not found", continue. If other error, log?
-		// Most MCP servers might return error if URI doesn't match.
-		// We continue to next upstream.
+	uc := &upstreamClient{
+		cfg:       ups,
+		client:    conn.client,
+		tools:     conn.tools,
+		prompts:   conn.prompts,
+		templates: conn.templates,
+		state:     StateRunning,
+		closeConn: conn.cancel,
+		stop:      stop,
+		kick:      make(chan struct{}, 1),
+		exited:    conn.exited,
 	}
 
-	return nil, fmt.Errorf("resource not found: %s", uri)
-}
+	uc.hasResources = conn.hasResources
 
-func (m *Manager) startOne(ctx context.Context, ups config.Upstream) error {
-	commandFunc := func(ctx context.Context, cmd string, env []string, args []string) (*exec.Cmd, error) {
-		c := exec.CommandContext(ctx, cmd, args...)
-		if ups.Workdir != "" {
-			c.Dir = ups.Workdir
-		}
-		c.Env = append(c.Env, env...)
-		return c, nil
+	m.mu.Lock()
+	old := m.upstreams[ups.Name]
+	if old != nil {
+		m.unindexResources(old)
 	}
+	m.upstreams[ups.Name] = uc
+	m.indexResources(uc, conn.resources)
+	m.mu.Unlock()
+
+	go m.supervise(supCtx, uc)
+	if old != nil {
+		logger.Global.Info("Stopping replaced upstream", zap.String("upstream", ups.Name))
+		m.shutdown(old)
+	}
+	return nil
+}
+
+// connection is a freshly spawned and initialized upstream client.
+type connection struct {
+	client       *client.Client
+	tools        []mcp.Tool
+	prompts      []mcp.Prompt
+	hasResources bool
+	resources    []mcp.Resource
+	templates    []mcp.ResourceTemplate
+	cancel       context.CancelFunc
+	exited       <-chan struct{}
+}
 
-	stdio := transport.NewStdioWithOptions(
-		ups.Command,
-		ups.Env,
-		ups.Args,
-		transport.WithCommandFunc(commandFunc),
-	)
+// connect spawns (or, for remote transports, dials) the upstream, performs the MCP
+// handshake and caches its tools, prompts and resources. A stdio child lives until the returned cancel
+// func is called or ctx is done.
+func (m *Manager) connect(ctx context.Context, ups config.Upstream) (*connection, error) {
+	connCtx, cancel := context.WithCancel(ctx)
 
-	cl := client.NewClient(stdio)
+	tr, err := newTransport(ups)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	cl := client.NewClient(&cancellingTransport{tr})
+	cl.OnNotification(func(n mcp.JSONRPCNotification) {
+		m.handleNotification(ups.Name, n)
+	})
 
 	// Start transport
-	if err := cl.Start(ctx); err != nil {
-		return fmt.Errorf("start stdio client for %s: %w", ups.Name, err)
+	if err := cl.Start(connCtx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("start %s client for %s: %w", ups.Transport, ups.Name, err)
 	}
 
 	// Initialize handshake
@@ -298,31 +744,88 @@ func (m *Manager) startOne(ctx context.Context, ups config.Upstream) error {
 			},
 		},
 	}
-	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
-	defer cancel()
+	initCtx, initCancel := context.WithTimeout(connCtx, ups.InitTimeout)
+	defer initCancel()
 
-	if _, err := cl.Initialize(ctx, initReq); err != nil {
+	initRes, err := cl.Initialize(initCtx, initReq)
+	if err != nil {
 		_ = cl.Close()
-		return fmt.Errorf("initialize %s: %w", ups.Name, err)
+		cancel()
+		return nil, fmt.Errorf("initialize %s: %w", ups.Name, err)
 	}
 
-	tools, err := cl.ListTools(context.Background(), mcp.ListToolsRequest{
-		PaginatedRequest: mcp.PaginatedRequest{
-			Request: mcp.Request{Method: string(mcp.MethodToolsList)},
-			Params:  mcp.PaginatedParams{},
-		},
-	})
-	if err != nil {
-		_ = cl.Close()
-		return fmt.Errorf("list tools for %s: %w", ups.Name, err)
+	// Tools and prompts are optional; only ask servers that advertise them.
+	var tools []mcp.Tool
+	if initRes.Capabilities.Tools != nil {
+		tools, err = listTools(initCtx, cl)
+		if err != nil {
+			_ = cl.Close()
+			cancel()
+			return nil, fmt.Errorf("list tools for %s: %w", ups.Name, err)
+		}
 	}
 
-	m.mu.Lock()
-	m.upstreams[ups.Name] = &upstreamClient{
-		cfg:    ups,
-		client: cl,
-		tools:  tools.Tools,
+	var prompts []mcp.Prompt
+	if initRes.Capabilities.Prompts != nil {
+		res, err := cl.ListPrompts(initCtx, mcp.ListPromptsRequest{
+			PaginatedRequest: mcp.PaginatedRequest{
+				Request: mcp.Request{Method: string(mcp.MethodPromptsList)},
+			},
+		})
+		if err != nil {
+			logger.Global.Warn("Failed to list prompts", zap.String("upstream", ups.Name), zap.Error(err))
+		} else {
+			prompts = res.Prompts
+		}
+	}
+
+	conn := &connection{client: cl, tools: tools, prompts: prompts, cancel: cancel}
+	if stdio, ok := tr.(*transport.Stdio); ok && stdio.Stderr() != nil {
+		exited := make(chan struct{})
+		go watchStderr(ups.Name, stdio.Stderr(), exited)
+		conn.exited = exited
+	}
+	if initRes.Capabilities.Resources != nil {
+		conn.hasResources = true
+		if res, err := cl.ListResources(initCtx, mcp.ListResourcesRequest{}); err != nil {
+			logger.Global.Warn("Failed to list resources", zap.String("upstream", ups.Name), zap.Error(err))
+		} else {
+			conn.resources = res.Resources
+		}
+		if res, err := cl.ListResourceTemplates(initCtx, mcp.ListResourceTemplatesRequest{}); err != nil {
+			logger.Global.Warn("Failed to list resource templates", zap.String("upstream", ups.Name), zap.Error(err))
+		} else {
+			conn.templates = res.ResourceTemplates
+		}
+	}
+	return conn, nil
+}
+
+// newTransport builds the mcp-go client transport matching the upstream's configuration.
+func newTransport(ups config.Upstream) (transport.Interface, error) {
+	switch ups.Transport {
+	case config.TransportSSE:
+		return transport.NewSSE(ups.URL, transport.WithHeaders(ups.Headers))
+	case config.TransportStreamableHTTP:
+		return transport.NewStreamableHTTP(ups.URL,
+			transport.WithHTTPHeaders(ups.Headers),
+			transport.WithContinuousListening(),
+		)
+	default:
+		commandFunc := func(ctx context.Context, cmd string, env []string, args []string) (*exec.Cmd, error) {
+			c := exec.CommandContext(ctx, cmd, args...)
+			if ups.Workdir != "" {
+				c.Dir = ups.Workdir
+			}
+			c.Env = append(c.Env, env...)
+			return c, nil
+		}
+
+		return transport.NewStdioWithOptions(
+			ups.Command,
+			ups.Env,
+			ups.Args,
+			transport.WithCommandFunc(commandFunc),
+		), nil
 	}
-	m.mu.Unlock()
-	return nil
 }
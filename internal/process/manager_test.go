@@ -0,0 +1,230 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/logger"
+	"gomcp-pilot/internal/schema"
+)
+
+func TestMain(m *testing.M) {
+	// Set once: supervisors of stopped managers may still log while other tests run.
+	logger.Global = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// newTestManager returns a manager with one upstream per validation mode, each
+// listing a "write" tool that requires a string path.
+func newTestManager() *Manager {
+	m := NewManager()
+	tool := mcp.Tool{Name: "write", InputSchema: mcp.ToolInputSchema{
+		Type:       "object",
+		Properties: map[string]any{"path": map[string]any{"type": "string"}},
+		Required:   []string{"path"},
+	}}
+	for _, mode := range []string{config.ValidationOff, config.ValidationWarn, config.ValidationEnforce} {
+		m.upstreams[mode] = &upstreamClient{
+			cfg:   config.Upstream{Name: mode, SchemaValidation: mode},
+			tools: []mcp.Tool{tool},
+		}
+	}
+	return m
+}
+
+func TestValidateEdited(t *testing.T) {
+	m := newTestManager()
+	tests := []struct {
+		upstream, tool, args string
+		wantInvalid          bool
+		wantErr              error
+	}{
+		{config.ValidationEnforce, "write", `{"path":"a"}`, false, nil},
+		{config.ValidationEnforce, "write", `{"path":1}`, true, nil},
+		{config.ValidationWarn, "write", `{"path":1}`, false, nil},
+		{config.ValidationOff, "write", `{}`, false, nil},
+		{config.ValidationOff, "missing", `{}`, false, ErrToolNotFound},
+		{config.ValidationEnforce, "missing", `{}`, false, ErrToolNotFound},
+	}
+	for _, tt := range tests {
+		err := m.ValidateEdited(tt.upstream, tt.tool, tt.args)
+		var invalid schema.Errors
+		switch {
+		case tt.wantErr != nil:
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s/%s %s: err = %v, want %v", tt.upstream, tt.tool, tt.args, err, tt.wantErr)
+			}
+		case tt.wantInvalid != errors.As(err, &invalid):
+			t.Errorf("%s/%s %s: err = %v, want schema errors: %v", tt.upstream, tt.tool, tt.args, err, tt.wantInvalid)
+		case !tt.wantInvalid && err != nil:
+			t.Errorf("%s/%s %s: unexpected err %v", tt.upstream, tt.tool, tt.args, err)
+		}
+	}
+	if err := m.ValidateEdited("nope", "write", `{}`); err == nil {
+		t.Error("unknown upstream accepted")
+	}
+}
+
+// serveUpstream serves srv over Streamable HTTP and returns the configuration
+// of an upstream named name reaching it, with the defaults config.Load fills in
+// and a health interval long enough that the supervisor stays out of the way.
+// Call it before startManager: closing the server waits for the manager's
+// connections, and cleanups run last in, first out.
+func serveUpstream(t *testing.T, name string, srv *server.MCPServer) config.Upstream {
+	t.Helper()
+	ts := server.NewTestStreamableHTTPServer(srv)
+	t.Cleanup(ts.Close)
+	return upstreamAt(name, ts.URL)
+}
+
+// upstreamAt returns the configuration of a Streamable HTTP upstream at url;
+// see serveUpstream.
+func upstreamAt(name, url string) config.Upstream {
+	return config.Upstream{
+		Name:             name,
+		Transport:        config.TransportStreamableHTTP,
+		URL:              url,
+		HealthInterval:   time.Hour,
+		InitTimeout:      5 * time.Second,
+		CallTimeout:      5 * time.Second,
+		RequestTimeout:   5 * time.Second,
+		SchemaValidation: config.ValidationOff,
+	}
+}
+
+// toolServer returns an upstream server offering a tool per name, each
+// answering with its name.
+func toolServer(names ...string) *server.MCPServer {
+	srv := server.NewMCPServer("upstream", "0", server.WithToolCapabilities(false))
+	for _, name := range names {
+		srv.AddTool(mcp.NewTool(name), func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText(name), nil
+		})
+	}
+	return srv
+}
+
+// startManager starts a manager for cfg and stops it when the test ends.
+func startManager(t *testing.T, cfg *config.Config) *Manager {
+	t.Helper()
+	m := NewManager()
+	if err := m.StartAll(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.StopAll)
+	return m
+}
+
+func TestGrantsAllowed(t *testing.T) {
+	cfg := &config.Config{
+		Upstreams: []config.Upstream{serveUpstream(t, "fs", toolServer("read", "write", "delete"))},
+		Rules: []config.Rule{
+			{Name: "writes", Tool: "write", Action: config.ActionAsk, Grants: true},
+			{Name: "deletes", Tool: "delete", Action: config.ActionAsk},
+		},
+	}
+	m := startManager(t, cfg)
+	asked := map[string]bool{}
+	m.SetInterceptor(func(ctx context.Context, upstream, tool, args string) Verdict {
+		asked[tool] = GrantsAllowed(ctx)
+		return Verdict{Approved: true, Approver: "test"}
+	})
+
+	for _, tool := range []string{"read", "write", "delete"} {
+		if _, err := m.CallTool(context.Background(), CallRequest{Upstream: "fs", Tool: tool}); err != nil {
+			t.Fatalf("%s: %v", tool, err)
+		}
+	}
+	want := map[string]bool{"read": true, "write": true, "delete": false}
+	for tool, grants := range want {
+		if got, ok := asked[tool]; !ok || got != grants {
+			t.Errorf("%s: asked %v, GrantsAllowed = %v; want %v", tool, ok, got, grants)
+		}
+	}
+	if GrantsAllowed(context.Background()) {
+		t.Error("GrantsAllowed without an interceptor call")
+	}
+}
+
+func TestReload(t *testing.T) {
+	kept := serveUpstream(t, "kept", toolServer("read"))
+	dropped := serveUpstream(t, "dropped", toolServer("read"))
+	changed := serveUpstream(t, "changed", toolServer("read"))
+	added := serveUpstream(t, "added", toolServer("write"))
+	m := startManager(t, &config.Config{Upstreams: []config.Upstream{kept, dropped, changed}})
+	before := maps.Clone(m.upstreams)
+
+	changed.CallTimeout = time.Minute
+	if err := m.Reload(context.Background(), &config.Config{Upstreams: []config.Upstream{kept, changed, added}}); err != nil {
+		t.Fatal(err)
+	}
+
+	m.mu.RLock()
+	after := maps.Clone(m.upstreams)
+	m.mu.RUnlock()
+	if len(after) != 3 || after["dropped"] != nil {
+		t.Fatalf("upstreams after reload: %v", slices.Sorted(maps.Keys(after)))
+	}
+	if after["kept"] != before["kept"] {
+		t.Error("unchanged upstream was restarted")
+	}
+	if after["changed"] == before["changed"] || after["changed"].cfg.CallTimeout != time.Minute {
+		t.Error("changed upstream was not restarted with its new configuration")
+	}
+	for _, name := range []string{"kept", "changed", "added"} {
+		if _, err := m.CallTool(context.Background(), CallRequest{Upstream: name, Tool: after[name].tools[0].Name}); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := m.CallTool(context.Background(), CallRequest{Upstream: "dropped", Tool: "read"}); err == nil {
+		t.Error("removed upstream still answers")
+	}
+}
+
+func TestReloadKeepsUpstreamThatFailsToStart(t *testing.T) {
+	ups := serveUpstream(t, "fs", toolServer("read"))
+	m := startManager(t, &config.Config{Upstreams: []config.Upstream{ups}})
+	before := m.upstreams["fs"]
+
+	// Nothing listens on the new address.
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	moved := ups
+	moved.URL = closed.URL
+	broken := upstreamAt("broken", closed.URL)
+	err := m.Reload(context.Background(), &config.Config{Upstreams: []config.Upstream{moved, broken}})
+	if err == nil || !strings.Contains(err.Error(), "fs") || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("err = %v, want failures for fs and broken", err)
+	}
+
+	m.mu.RLock()
+	cur, added := m.upstreams["fs"], m.upstreams["broken"]
+	m.mu.RUnlock()
+	if cur != before || cur.cfg.URL != ups.URL {
+		t.Error("upstream that failed to restart was replaced")
+	}
+	if added != nil {
+		t.Error("upstream that failed to start was added")
+	}
+	if _, err := m.CallTool(context.Background(), CallRequest{Upstream: "fs", Tool: "read"}); err != nil {
+		t.Errorf("old instance stopped answering: %v", err)
+	}
+
+	// The next reload tries again.
+	if err := m.Reload(context.Background(), &config.Config{Upstreams: []config.Upstream{ups}}); err != nil {
+		t.Errorf("reload back to the working config: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/logger"
+)
+
+// Notification methods the manager handles itself before forwarding.
+const (
+	methodProgress = "notifications/progress"
+	methodMessage  = "notifications/message"
+)
+
+// ProgressFunc receives the params of an upstream progress notification, minus
+// the progress token, so the caller can re-emit it under its own token.
+type ProgressFunc func(params map[string]any)
+
+var progressSeq atomic.Int64
+
+// SetNotifier registers fn to receive upstream notifications that should be
+// re-emitted to downstream clients. Progress notifications are delivered to the
+// originating CallRequest instead. For list_changed notifications fn is called
+// after the manager has refreshed its cached tools, prompts or resources.
+func (m *Manager) SetNotifier(fn func(upstream string, n mcp.JSONRPCNotification)) {
+	m.mu.Lock()
+	m.notifier = fn
+	m.mu.Unlock()
+}
+
+// registerProgress allocates a gateway-unique progress token routed to fn.
+func (m *Manager) registerProgress(fn ProgressFunc) (token string, release func()) {
+	token = fmt.Sprintf("gomcp-%d", progressSeq.Add(1))
+	m.progressMu.Lock()
+	m.progress[token] = fn
+	m.progressMu.Unlock()
+	return token, func() {
+		m.progressMu.Lock()
+		delete(m.progress, token)
+		m.progressMu.Unlock()
+	}
+}
+
+// handleNotification is registered on every upstream client. It runs on the
+// transport's read loop, so anything that talks back to the upstream must not block it.
+func (m *Manager) handleNotification(upstream string, n mcp.JSONRPCNotification) {
+	switch n.Method {
+	case methodProgress:
+		token := fmt.Sprint(n.Params.AdditionalFields["progressToken"])
+		m.progressMu.Lock()
+		fn := m.progress[token]
+		m.progressMu.Unlock()
+		if fn == nil {
+			return
+		}
+		params := make(map[string]any, len(n.Params.AdditionalFields))
+		for k, v := range n.Params.AdditionalFields {
+			if k != "progressToken" {
+				params[k] = v
+			}
+		}
+		fn(params)
+
+	case mcp.MethodNotificationToolsListChanged,
+		mcp.MethodNotificationPromptsListChanged,
+		mcp.MethodNotificationResourcesListChanged:
+		go func() {
+			if err := m.refresh(upstream, n.Method); err != nil {
+				logger.Global.Warn("Failed to refresh upstream after list_changed",
+					zap.String("upstream", upstream),
+					zap.String("method", n.Method),
+					zap.Error(err))
+				return
+			}
+			m.notify(upstream, n)
+		}()
+
+	default:
+		m.notify(upstream, n)
+	}
+}
+
+func (m *Manager) notify(upstream string, n mcp.JSONRPCNotification) {
+	m.mu.RLock()
+	fn := m.notifier
+	m.mu.RUnlock()
+	if fn != nil {
+		fn(upstream, n)
+	}
+}
+
+// refresh re-fetches the list named by a list_changed method and updates the cache.
+func (m *Manager) refresh(upstream, method string) error {
+	ups, cl, err := m.clientFor(upstream)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ups.cfg.RequestTimeout)
+	defer cancel()
+
+	switch method {
+	case mcp.MethodNotificationToolsListChanged:
+		tools, err := listTools(ctx, cl)
+		if err != nil {
+			return err
+		}
+		m.mu.Lock()
+		ups.tools = tools
+		m.mu.Unlock()
+
+	case mcp.MethodNotificationPromptsListChanged:
+		res, err := cl.ListPrompts(ctx, mcp.ListPromptsRequest{})
+		if err != nil {
+			return err
+		}
+		m.mu.Lock()
+		ups.prompts = res.Prompts
+		m.mu.Unlock()
+
+	case mcp.MethodNotificationResourcesListChanged:
+		res, err := cl.ListResources(ctx, mcp.ListResourcesRequest{})
+		if err != nil {
+			return err
+		}
+		templates, err := cl.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+		m.mu.Lock()
+		m.indexResources(ups, res.Resources)
+		if err == nil {
+			ups.templates = templates.ResourceTemplates
+		}
+		m.mu.Unlock()
+	}
+
+	logger.Global.Info("Refreshed upstream after list_changed",
+		zap.String("upstream", upstream),
+		zap.String("method", method))
+	return nil
+}
@@ -0,0 +1,107 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/auth"
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/logger"
+	"gomcp-pilot/internal/store"
+)
+
+// PromptDescriptor is returned to HTTP clients when listing prompts.
+type PromptDescriptor struct {
+	Upstream    string               `json:"upstream"`
+	Name        string               `json:"name"`
+	Description string               `json:"description,omitempty"`
+	Arguments   []mcp.PromptArgument `json:"arguments,omitempty"`
+}
+
+// ListPrompts aggregates the prompts cached from each upstream. If upstreamFilter
+// is non-empty, only that upstream is returned.
+func (m *Manager) ListPrompts(upstreamFilter string) ([]PromptDescriptor, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if upstreamFilter != "" && m.upstreams[upstreamFilter] == nil {
+		return nil, fmt.Errorf("upstream %s not found", upstreamFilter)
+	}
+
+	var result []PromptDescriptor
+	for name, ups := range m.upstreams {
+		if upstreamFilter != "" && name != upstreamFilter {
+			continue
+		}
+		for _, p := range ups.prompts {
+			result = append(result, PromptDescriptor{
+				Upstream:    name,
+				Name:        p.Name,
+				Description: p.Description,
+				Arguments:   p.Arguments,
+			})
+		}
+	}
+	return result, nil
+}
+
+// GetPrompt renders a prompt template on the specified upstream and records
+// it in the audit log.
+func (m *Manager) GetPrompt(ctx context.Context, upstream, name string, args map[string]string) (*mcp.GetPromptResult, error) {
+	ctx = ensureRequestID(ctx)
+	start := time.Now()
+	res, err := m.getPrompt(ctx, upstream, name, args)
+
+	rec := store.CallRecord{Operation: OpGetPrompt, Upstream: upstream, Tool: name}
+	if args != nil {
+		rec.Arguments = marshalResult(args)
+	}
+	if res != nil {
+		type message struct {
+			Role    mcp.Role        `json:"role"`
+			Content recordedContent `json:"content"`
+		}
+		r := newResultRecorder()
+		messages := make([]message, 0, len(res.Messages))
+		for _, msg := range res.Messages {
+			messages = append(messages, message{Role: msg.Role, Content: r.content(msg.Content)})
+		}
+		rec.Result = marshalResult(map[string]any{"description": res.Description, "messages": messages})
+	}
+	record(ctx, rec, time.Since(start), err)
+	return res, err
+}
+
+func (m *Manager) getPrompt(ctx context.Context, upstream, name string, args map[string]string) (*mcp.GetPromptResult, error) {
+	if id := auth.FromContext(ctx); !id.Can(config.ScopeRead) || !id.AllowsUpstream(upstream) {
+		return nil, fmt.Errorf("%w: token %s may not get prompts from %s", auth.ErrForbidden, id.Caller(), upstream)
+	}
+	ups, cl, err := m.clientFor(upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ups.cfg.RequestTimeout)
+	defer cancel()
+
+	res, err := cl.GetPrompt(ctx, mcp.GetPromptRequest{
+		Request: mcp.Request{Method: string(mcp.MethodPromptsGet)},
+		Params: mcp.GetPromptParams{
+			Name:      name,
+			Arguments: args,
+		},
+	})
+	if err != nil {
+		logger.Global.Warn("GetPrompt failed",
+			zap.String("request_id", RequestIDFromContext(ctx)),
+			zap.String("upstream", upstream),
+			zap.String("prompt", name),
+			zap.Error(err))
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,255 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/auth"
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/logger"
+	"gomcp-pilot/internal/store"
+)
+
+// ResourceDescriptor represents an available resource from an upstream.
+type ResourceDescriptor struct {
+	Upstream    string `json:"upstream"`
+	Name        string `json:"name"`
+	Uri         string `json:"uri"`
+	MimeType    string `json:"mimeType,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// ResourceTemplateDescriptor represents a parameterized resource from an upstream.
+type ResourceTemplateDescriptor struct {
+	Upstream    string `json:"upstream"`
+	Name        string `json:"name"`
+	UriTemplate string `json:"uriTemplate"`
+	MimeType    string `json:"mimeType,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// resourceTarget is a running upstream that advertises the resources capability.
+type resourceTarget struct {
+	name   string
+	ups    *upstreamClient
+	client *client.Client
+}
+
+// resourceTargets snapshots the running upstreams that serve resources, sorted by name.
+func (m *Manager) resourceTargets(upstreamFilter string) []resourceTarget {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var targets []resourceTarget
+	for name, ups := range m.upstreams {
+		if upstreamFilter != "" && name != upstreamFilter {
+			continue
+		}
+		if ups.state != StateRunning || !ups.hasResources {
+			continue
+		}
+		targets = append(targets, resourceTarget{name: name, ups: ups, client: ups.client})
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].name < targets[j].name })
+	return targets
+}
+
+// ListResources aggregates resources across upstreams and refreshes the URI index
+// used to route ReadResource.
+func (m *Manager) ListResources(upstreamFilter string) ([]ResourceDescriptor, error) {
+	var result []ResourceDescriptor
+	for _, t := range m.resourceTargets(upstreamFilter) {
+		ctx, cancel := context.WithTimeout(context.Background(), t.ups.cfg.RequestTimeout)
+		res, err := t.client.ListResources(ctx, mcp.ListResourcesRequest{})
+		cancel()
+		if err != nil {
+			// Log and continue with the remaining upstreams.
+			logger.Global.Warn("Failed to list resources", zap.String("upstream", t.name), zap.Error(err))
+			continue
+		}
+
+		m.mu.Lock()
+		if m.upstreams[t.name] == t.ups {
+			m.indexResources(t.ups, res.Resources)
+		}
+		m.mu.Unlock()
+
+		for _, r := range res.Resources {
+			result = append(result, ResourceDescriptor{
+				Upstream:    t.name,
+				Name:        r.Name,
+				Uri:         r.URI,
+				MimeType:    r.MIMEType,
+				Description: r.Description,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// ListResourceTemplates aggregates resource templates across upstreams.
+func (m *Manager) ListResourceTemplates(upstreamFilter string) ([]ResourceTemplateDescriptor, error) {
+	var result []ResourceTemplateDescriptor
+	for _, t := range m.resourceTargets(upstreamFilter) {
+		ctx, cancel := context.WithTimeout(context.Background(), t.ups.cfg.RequestTimeout)
+		res, err := t.client.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+		cancel()
+		if err != nil {
+			logger.Global.Warn("Failed to list resource templates", zap.String("upstream", t.name), zap.Error(err))
+			continue
+		}
+
+		m.mu.Lock()
+		if m.upstreams[t.name] == t.ups {
+			t.ups.templates = res.ResourceTemplates
+		}
+		m.mu.Unlock()
+
+		for _, rt := range res.ResourceTemplates {
+			if rt.URITemplate == nil {
+				continue
+			}
+			result = append(result, ResourceTemplateDescriptor{
+				Upstream:    t.name,
+				Name:        rt.Name,
+				UriTemplate: rt.URITemplate.Raw(),
+				MimeType:    rt.MIMEType,
+				Description: rt.Description,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// ErrResourceNotFound is returned for a URI no upstream lists or matches with
+// a resource template.
+var ErrResourceNotFound = errors.New("resource not found")
+
+// ReadResource reads a resource from the upstream that owns uri and records
+// the read in the audit log. Ownership is resolved from the URI index first,
+// then by matching resource templates. For a URI that matches neither, the
+// index is refreshed once in case the upstream added it since it was listed.
+func (m *Manager) ReadResource(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+	ctx = ensureRequestID(ctx)
+	start := time.Now()
+	owner, res, err := m.readResource(ctx, uri)
+
+	rec := store.CallRecord{Operation: OpReadResource, Upstream: owner, Tool: uri}
+	if res != nil {
+		r := newResultRecorder()
+		contents := make([]recordedContent, 0, len(res.Contents))
+		for _, c := range res.Contents {
+			contents = append(contents, r.resource(c))
+		}
+		rec.Result = marshalResult(map[string]any{"contents": contents})
+	}
+	record(ctx, rec, time.Since(start), err)
+	return res, err
+}
+
+// readResource returns the resource and the upstream that served it, or the
+// one it was routed to when the read failed.
+func (m *Manager) readResource(ctx context.Context, uri string) (string, *mcp.ReadResourceResult, error) {
+	log := logger.Global.With(zap.String("request_id", RequestIDFromContext(ctx)))
+	req := mcp.ReadResourceRequest{
+		Request: mcp.Request{Method: string(mcp.MethodResourcesRead)},
+		Params: mcp.ReadResourceParams{
+			URI: uri,
+		},
+	}
+
+	id := auth.FromContext(ctx)
+	if !id.Can(config.ScopeRead) {
+		return "", nil, fmt.Errorf("%w: token %s may not read resources", auth.ErrForbidden, id.Caller())
+	}
+
+	owner := m.ResourceOwner(uri)
+	if owner == "" {
+		m.refreshResourceIndex()
+		if owner = m.ResourceOwner(uri); owner == "" {
+			return "", nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+		}
+	}
+	if !id.AllowsUpstream(owner) {
+		return owner, nil, fmt.Errorf("%w: token %s may not use upstream %s", auth.ErrForbidden, id.Caller(), owner)
+	}
+	ups, cl, err := m.clientFor(owner)
+	if err != nil {
+		return owner, nil, err
+	}
+	readCtx, cancel := context.WithTimeout(ctx, ups.cfg.RequestTimeout)
+	defer cancel()
+	res, err := cl.ReadResource(readCtx, req)
+	if err != nil {
+		return owner, nil, fmt.Errorf("read %s from %s: %w", uri, owner, err)
+	}
+	log.Info("ReadResource success", zap.String("upstream", owner), zap.String("uri", uri))
+	return owner, res, nil
+}
+
+// refreshResourceIndex re-lists the resources and resource templates of every
+// upstream, updating the index ResourceOwner consults.
+func (m *Manager) refreshResourceIndex() {
+	_, _ = m.ListResources("")
+	_, _ = m.ListResourceTemplates("")
+}
+
+// ResourceOwner returns the upstream that serves uri, or "" if unknown.
+func (m *Manager) ResourceOwner(uri string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if owner, ok := m.resourceIndex[uri]; ok {
+		return owner
+	}
+
+	names := make([]string, 0, len(m.upstreams))
+	for name := range m.upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, rt := range m.upstreams[name].templates {
+			if rt.URITemplate != nil && rt.URITemplate.Regexp().MatchString(uri) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// indexResources replaces the index entries owned by ups with resources.
+// The caller must hold m.mu for writing.
+func (m *Manager) indexResources(ups *upstreamClient, resources []mcp.Resource) {
+	m.unindexResources(ups)
+	ups.resources = resources
+	for _, r := range resources {
+		if owner, ok := m.resourceIndex[r.URI]; ok && owner != ups.cfg.Name {
+			logger.Global.Warn("Resource URI served by multiple upstreams, keeping first",
+				zap.String("uri", r.URI),
+				zap.String("owner", owner),
+				zap.String("upstream", ups.cfg.Name))
+			continue
+		}
+		m.resourceIndex[r.URI] = ups.cfg.Name
+	}
+}
+
+// unindexResources drops the index entries owned by ups.
+// The caller must hold m.mu for writing.
+func (m *Manager) unindexResources(ups *upstreamClient) {
+	for _, r := range ups.resources {
+		if m.resourceIndex[r.URI] == ups.cfg.Name {
+			delete(m.resourceIndex, r.URI)
+		}
+	}
+}
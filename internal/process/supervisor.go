@@ -0,0 +1,244 @@
+package process
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"go.uber.org/zap"
+
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/logger"
+)
+
+// State is the lifecycle state of a supervised upstream.
+type State string
+
+const (
+	StateRunning    State = "Running"
+	StateRestarting State = "Restarting"
+	StateFailed     State = "Failed"
+)
+
+const (
+	pingTimeout    = 5 * time.Second
+	backoffBase    = 1 * time.Second
+	backoffMax     = 30 * time.Second
+	stableDuration = time.Minute // healthy this long after a restart resets the budget
+)
+
+// UpstreamState is a point-in-time snapshot of an upstream's health.
+type UpstreamState struct {
+	Name      string `json:"name"`
+	State     State  `json:"state"`
+	Restarts  int    `json:"restarts"`
+	LastError string `json:"last_error,omitempty"`
+
+	Config config.Upstream `json:"-"`
+}
+
+// UpstreamStates reports the supervisor state of every upstream.
+func (m *Manager) UpstreamStates() map[string]UpstreamState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	states := make(map[string]UpstreamState, len(m.upstreams))
+	for name, ups := range m.upstreams {
+		states[name] = UpstreamState{
+			Name:      name,
+			State:     ups.state,
+			Restarts:  ups.restarts,
+			LastError: ups.lastError,
+			Config:    ups.cfg,
+		}
+	}
+	return states
+}
+
+// requestCheck asks the supervisor to run a health check without waiting for the next tick.
+func (u *upstreamClient) requestCheck() {
+	select {
+	case u.kick <- struct{}{}:
+	default:
+	}
+}
+
+// watchStderr logs what a stdio child writes to stderr, which also keeps the
+// pipe from filling up, and closes exited when the pipe reaches EOF, i.e. when
+// the child exits.
+func watchStderr(upstream string, r io.Reader, exited chan<- struct{}) {
+	defer close(exited)
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			logger.Global.Info("Upstream stderr", zap.String("upstream", upstream), zap.String("line", line))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// errProcessExited is the restart cause when a stdio child exits on its own.
+var errProcessExited = errors.New("process exited")
+
+// supervise pings the upstream periodically and restarts it when it stops
+// answering, or at once when its stdio child exits. It returns when ctx is
+// cancelled or the restart budget is exhausted.
+func (m *Manager) supervise(ctx context.Context, ups *upstreamClient) {
+	name := ups.cfg.Name
+	ticker := time.NewTicker(ups.cfg.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		m.mu.RLock()
+		exited := ups.exited
+		m.mu.RUnlock()
+
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-ups.kick:
+		case <-exited:
+			err = errProcessExited
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			m.mu.RLock()
+			cl := ups.client
+			m.mu.RUnlock()
+
+			pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+			err = cl.Ping(pingCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+		}
+
+		if err == nil {
+			m.mu.Lock()
+			if ups.restarts > 0 && time.Since(ups.lastRestart) > stableDuration {
+				ups.restarts = 0
+			}
+			m.mu.Unlock()
+			continue
+		}
+
+		logger.Global.Warn("Upstream health check failed",
+			zap.String("upstream", name),
+			zap.Error(err))
+
+		if !m.restart(ctx, ups, err) {
+			return
+		}
+	}
+}
+
+// backoff returns the delay before restart attempt n, counting from 1:
+// backoffBase doubled for every earlier attempt, up to backoffMax.
+func backoff(n int) time.Duration {
+	delay := backoffBase
+	for i := 1; i < n && delay < backoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, backoffMax)
+}
+
+// restart replaces the upstream's client with a fresh process, backing off
+// exponentially between attempts. It returns false once the upstream is
+// marked failed or the supervisor is stopping.
+func (m *Manager) restart(ctx context.Context, ups *upstreamClient, cause error) bool {
+	name := ups.cfg.Name
+
+	m.mu.Lock()
+	ups.state = StateRestarting
+	ups.lastError = cause.Error()
+	old, closeOld := ups.client, ups.closeConn
+	m.mu.Unlock()
+
+	// The child is unhealthy, so kill it before closing rather than waiting for a graceful exit.
+	closeOld()
+	_ = old.Close()
+
+	for {
+		m.mu.Lock()
+		if ups.restarts >= ups.cfg.RestartLimit() {
+			ups.state = StateFailed
+			m.mu.Unlock()
+			logger.Global.Error("Upstream exceeded restart budget, giving up",
+				zap.String("upstream", name),
+				zap.Int("max_restarts", ups.cfg.RestartLimit()))
+			return false
+		}
+		ups.restarts++
+		attempt := ups.restarts
+		m.mu.Unlock()
+
+		delay := backoff(attempt)
+		logger.Global.Info("Restarting upstream",
+			zap.String("upstream", name),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", delay))
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+
+		conn, err := m.connect(ctx, ups.cfg)
+		if err != nil {
+			logger.Global.Warn("Upstream restart failed",
+				zap.String("upstream", name),
+				zap.Int("attempt", attempt),
+				zap.Error(err))
+			m.mu.Lock()
+			ups.lastError = err.Error()
+			m.mu.Unlock()
+			continue
+		}
+
+		m.mu.Lock()
+		if ctx.Err() != nil || m.upstreams[name] != ups {
+			m.mu.Unlock()
+			_ = conn.client.Close()
+			conn.cancel()
+			return false
+		}
+		ups.client = conn.client
+		ups.tools = conn.tools
+		ups.prompts = conn.prompts
+		ups.hasResources = conn.hasResources
+		ups.templates = conn.templates
+		m.indexResources(ups, conn.resources)
+		ups.closeConn = conn.cancel
+		ups.exited = conn.exited
+		ups.state = StateRunning
+		ups.lastRestart = time.Now()
+		m.mu.Unlock()
+
+		logger.Global.Info("Upstream restarted",
+			zap.String("upstream", name),
+			zap.Int("attempt", attempt),
+			zap.Int("tools", len(conn.tools)))
+		// The new process may offer different tools, prompts or resources; the
+		// notifier re-syncs every list, so one notification covers them all.
+		m.notify(name, mcp.JSONRPCNotification{
+			JSONRPC:      mcp.JSONRPC_VERSION,
+			Notification: mcp.Notification{Method: mcp.MethodNotificationToolsListChanged},
+		})
+		return true
+	}
+}
@@ -0,0 +1,133 @@
+package process
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	"gomcp-pilot/internal/config"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		{6, backoffMax},
+		{64, backoffMax},
+		{1 << 30, backoffMax},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// flakyUpstream is an upstream whose server can be taken down and brought back.
+type flakyUpstream struct {
+	down  atomic.Bool
+	calls atomic.Int32 // requests served while up
+}
+
+// serveFlaky serves a single tool from an upstream that fails every request
+// while down is set.
+func serveFlaky(t *testing.T, name string, maxRestarts int) (*flakyUpstream, config.Upstream) {
+	t.Helper()
+	f := &flakyUpstream{}
+	h := server.NewStreamableHTTPServer(toolServer("read"))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.down.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		f.calls.Add(1)
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	ups := upstreamAt(name, ts.URL)
+	ups.HealthInterval = 20 * time.Millisecond
+	ups.MaxRestarts = &maxRestarts
+	return f, ups
+}
+
+// waitState waits until the upstream reaches want and returns its state.
+func waitState(t *testing.T, m *Manager, name string, want State) UpstreamState {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		st := m.UpstreamStates()[name]
+		if st.State == want {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s is %s, want %s", name, st.State, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSupervisorRestartsAfterFailedPing(t *testing.T) {
+	f, ups := serveFlaky(t, "fs", 3)
+	m := startManager(t, &config.Config{Upstreams: []config.Upstream{ups}})
+
+	f.down.Store(true)
+	st := waitState(t, m, "fs", StateRestarting)
+	if st.LastError == "" {
+		t.Error("restart without a recorded cause")
+	}
+	served := f.calls.Load()
+	f.down.Store(false)
+
+	st = waitState(t, m, "fs", StateRunning)
+	if st.Restarts != 1 {
+		t.Errorf("restarts = %d, want 1", st.Restarts)
+	}
+	if f.calls.Load() == served {
+		t.Error("upstream was not reconnected")
+	}
+	if _, err := m.CallTool(t.Context(), CallRequest{Upstream: "fs", Tool: "read", Approval: &Approval{}}); err != nil {
+		t.Errorf("call after restart: %v", err)
+	}
+}
+
+func TestSupervisorWithoutRestarts(t *testing.T) {
+	f, ups := serveFlaky(t, "fs", 0)
+	m := startManager(t, &config.Config{Upstreams: []config.Upstream{ups}})
+
+	f.down.Store(true)
+	st := waitState(t, m, "fs", StateFailed)
+	if st.Restarts != 0 || st.LastError == "" {
+		t.Errorf("state = %+v, want failed without restarting", st)
+	}
+	if _, err := m.CallTool(t.Context(), CallRequest{Upstream: "fs", Tool: "read"}); err == nil {
+		t.Error("call to a failed upstream succeeded")
+	}
+}
+
+func TestSupervisorGivesUp(t *testing.T) {
+	f, ups := serveFlaky(t, "fs", 1)
+	m := startManager(t, &config.Config{Upstreams: []config.Upstream{ups}})
+
+	// The one restart allowed fails too, after backoff(1).
+	f.down.Store(true)
+	st := waitState(t, m, "fs", StateFailed)
+	if st.Restarts != 1 {
+		t.Errorf("restarts = %d, want 1", st.Restarts)
+	}
+
+	// A failed upstream stays down even once its server is back.
+	f.down.Store(false)
+	time.Sleep(100 * time.Millisecond)
+	if st := m.UpstreamStates()["fs"]; st.State != StateFailed {
+		t.Errorf("state = %s after the server came back, want %s", st.State, StateFailed)
+	}
+}
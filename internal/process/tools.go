@@ -0,0 +1,77 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// toolsListSeq numbers the tools/list requests listTools sends itself.
+var toolsListSeq atomic.Int64
+
+// listTools fetches every page of the upstream's tools. mcp.Tool decodes an
+// inputSchema into type, properties, required and $defs only, dropping
+// additionalProperties, allOf, anyOf, oneOf and the rest, so the request goes
+// out on the client's transport and each tool keeps the schema exactly as the
+// upstream sent it in RawInputSchema. String request IDs cannot collide with
+// the numeric ones the client assigns.
+func listTools(ctx context.Context, cl *client.Client) ([]mcp.Tool, error) {
+	var (
+		tools  []mcp.Tool
+		cursor mcp.Cursor
+	)
+	for {
+		resp, err := cl.GetTransport().SendRequest(ctx, transport.JSONRPCRequest{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      mcp.NewRequestId(fmt.Sprintf("gomcp-tools-list-%d", toolsListSeq.Add(1))),
+			Method:  string(mcp.MethodToolsList),
+			Params:  mcp.PaginatedParams{Cursor: cursor},
+		})
+		if err != nil {
+			return nil, transport.NewError(err)
+		}
+		if resp.Error != nil {
+			return nil, resp.Error.AsError()
+		}
+
+		var page struct {
+			Tools      []json.RawMessage `json:"tools"`
+			NextCursor mcp.Cursor        `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(resp.Result, &page); err != nil {
+			return nil, fmt.Errorf("decode tools/list result: %w", err)
+		}
+		for _, raw := range page.Tools {
+			var tool mcp.Tool
+			if err := json.Unmarshal(raw, &tool); err != nil {
+				return nil, fmt.Errorf("decode tool: %w", err)
+			}
+			var schema struct {
+				InputSchema json.RawMessage `json:"inputSchema"`
+			}
+			if err := json.Unmarshal(raw, &schema); err == nil && len(schema.InputSchema) > 0 && string(schema.InputSchema) != "null" {
+				tool.InputSchema = mcp.ToolInputSchema{}
+				tool.RawInputSchema = schema.InputSchema
+			}
+			tools = append(tools, tool)
+		}
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// inputSchema returns the schema a tool advertised: the raw one when listTools
+// kept it, the decoded one otherwise.
+func inputSchema(t mcp.Tool) any {
+	if t.RawInputSchema != nil {
+		return t.RawInputSchema
+	}
+	return t.InputSchema
+}
@@ -0,0 +1,130 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"gomcp-pilot/internal/config"
+	"gomcp-pilot/internal/schema"
+	"gomcp-pilot/internal/store"
+)
+
+const writeSchema = `{
+	"type": "object",
+	"properties": {"path": {"$ref": "#/$defs/path"}},
+	"required": ["path"],
+	"additionalProperties": false,
+	"$defs": {"path": {"type": "string", "pattern": "^/"}}
+}`
+
+// listFrom lists the tools of an in-process server offering write, with
+// writeSchema, and plain, with a schema mcp.Tool can represent.
+func listFrom(t *testing.T) []mcp.Tool {
+	t.Helper()
+	srv := server.NewMCPServer("upstream", "0", server.WithToolCapabilities(false))
+	noop := func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+	srv.AddTool(mcp.NewToolWithRawSchema("write", "", json.RawMessage(writeSchema)), noop)
+	srv.AddTool(mcp.NewTool("plain", mcp.WithString("q", mcp.Required())), noop)
+
+	cl, err := client.NewInProcessClient(srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cl.Close() })
+	ctx := context.Background()
+	if err := cl.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.Initialize(ctx, mcp.InitializeRequest{Params: mcp.InitializeParams{ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION}}); err != nil {
+		t.Fatal(err)
+	}
+	tools, err := listTools(ctx, cl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tools
+}
+
+func TestListToolsKeepsRawSchema(t *testing.T) {
+	tools := listFrom(t)
+	if len(tools) != 2 {
+		t.Fatalf("got %d tools, want 2", len(tools))
+	}
+	for _, tool := range tools {
+		if tool.RawInputSchema == nil || tool.InputSchema.Type != "" {
+			t.Errorf("%s: RawInputSchema = %s, InputSchema = %+v; want only the raw schema", tool.Name, tool.RawInputSchema, tool.InputSchema)
+		}
+		if _, err := json.Marshal(tool); err != nil {
+			t.Errorf("%s: tool no longer marshals: %v", tool.Name, err)
+		}
+	}
+	if !strings.Contains(string(tools[1].RawInputSchema), "additionalProperties") {
+		t.Errorf("write schema lost keywords: %s", tools[1].RawInputSchema)
+	}
+}
+
+func TestPrecheckArguments(t *testing.T) {
+	m := newTestManager()
+	tools := listFrom(t)
+	for _, ups := range m.upstreams {
+		ups.tools = tools
+	}
+	if err := store.InitStore(store.Memory); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		store.Close()
+		store.DB = nil
+	})
+
+	tests := []struct {
+		upstream string
+		args     any
+		want     []string // pointers of the rejection; nil for none
+	}{
+		{config.ValidationEnforce, map[string]any{"path": "/tmp/a"}, nil},
+		{config.ValidationEnforce, map[string]any{"path": "tmp", "mode": "w"}, []string{"/mode", "/path"}},
+		{config.ValidationEnforce, nil, []string{"/path"}},
+		{config.ValidationWarn, map[string]any{"path": "tmp"}, nil},
+		{config.ValidationOff, map[string]any{"path": "tmp"}, nil},
+	}
+	rejected := 0
+	for _, tt := range tests {
+		err := m.PrecheckArguments(context.Background(), CallRequest{Upstream: tt.upstream, Tool: "write", Arguments: tt.args})
+		var invalid schema.Errors
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s %v: unexpected err %v", tt.upstream, tt.args, err)
+			}
+			continue
+		}
+		rejected++
+		if !errors.As(err, &invalid) {
+			t.Fatalf("%s %v: err = %v, want schema errors", tt.upstream, tt.args, err)
+		}
+		var got []string
+		for _, e := range invalid {
+			got = append(got, e.Pointer)
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s %v: pointers = %q, want %q", tt.upstream, tt.args, got, tt.want)
+		}
+	}
+
+	var n int
+	if err := store.DB.QueryRow(`SELECT COUNT(*) FROM request_logs WHERE status = ?`, store.StatusError).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != rejected {
+		t.Errorf("audit log holds %d rejected calls, want %d", n, rejected)
+	}
+}
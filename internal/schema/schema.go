@@ -0,0 +1,416 @@
+// Package schema validates tool arguments against the JSON Schema an upstream
+// advertises as a tool's inputSchema. It implements the subset of draft
+// 2020-12 that MCP servers use in practice; unknown keywords are ignored.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error is a single validation failure.
+type Error struct {
+	Pointer string `json:"pointer"` // JSON pointer (RFC 6901) to the offending value; "" is the whole document
+	Message string `json:"message"`
+}
+
+func (e Error) Error() string {
+	p := e.Pointer
+	if p == "" {
+		p = "(root)"
+	}
+	return p + ": " + e.Message
+}
+
+// Errors lists every failure found in a document.
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "invalid arguments: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks doc against schema and returns nil or Errors. Both may be
+// any value encoding/json can marshal (e.g. mcp.ToolInputSchema); strings,
+// []byte and json.RawMessage are taken as JSON text. A schema that cannot be
+// applied, e.g. because a $ref does not resolve, is reported as a plain error.
+func Validate(schema, doc any) error {
+	s, err := normalize(schema)
+	if err != nil {
+		return fmt.Errorf("decode schema: %w", err)
+	}
+	d, err := normalize(doc)
+	if err != nil {
+		return fmt.Errorf("decode document: %w", err)
+	}
+	vr := &validator{root: s}
+	var errs Errors
+	vr.validate(s, d, "", &errs)
+	if vr.err != nil {
+		return vr.err
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// maxRefDepth bounds how many $refs may be followed at once, so a schema
+// whose references loop without consuming the document cannot recurse forever.
+const maxRefDepth = 64
+
+// validator carries what a walk over one document needs besides the schema
+// at hand: the root schema local $refs point into, and the first reason the
+// schema itself could not be used.
+type validator struct {
+	root any
+	refs int
+	err  error
+}
+
+// resolve finds the schema a local $ref such as "#/$defs/path" points to.
+// References to other documents are not supported.
+func (vr *validator) resolve(ref string) (any, error) {
+	frag, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("$ref %q: only references within the schema are supported", ref)
+	}
+	frag, err := url.PathUnescape(frag)
+	if err != nil {
+		return nil, fmt.Errorf("$ref %q: %w", ref, err)
+	}
+	cur := vr.root
+	if frag == "" {
+		return cur, nil
+	}
+	if !strings.HasPrefix(frag, "/") {
+		return nil, fmt.Errorf("$ref %q: anchors are not supported", ref)
+	}
+	for _, token := range strings.Split(frag[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch node := cur.(type) {
+		case map[string]any:
+			if cur, ok = node[token]; !ok {
+				return nil, fmt.Errorf("$ref %q: %q not found", ref, token)
+			}
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("$ref %q: no item %q", ref, token)
+			}
+			cur = node[i]
+		default:
+			return nil, fmt.Errorf("$ref %q: %q not found", ref, token)
+		}
+	}
+	return cur, nil
+}
+
+func normalize(v any) (any, error) {
+	var b []byte
+	switch v := v.(type) {
+	case json.RawMessage:
+		b = v
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		var err error
+		if b, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var out any
+	err := json.Unmarshal(b, &out)
+	return out, err
+}
+
+func (vr *validator) validate(schema, v any, ptr string, errs *Errors) {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			*errs = append(*errs, Error{ptr, "no value is allowed here"})
+		}
+		return
+	case map[string]any:
+		vr.validateObjectSchema(s, v, ptr, errs)
+	}
+}
+
+func (vr *validator) validateObjectSchema(s map[string]any, v any, ptr string, errs *Errors) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, Error{ptr, fmt.Sprintf(format, args...)})
+	}
+
+	// Like any other keyword, $ref applies alongside its siblings.
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := vr.resolve(ref)
+		switch {
+		case err != nil:
+			vr.fail(err)
+		case vr.refs >= maxRefDepth:
+			vr.fail(fmt.Errorf("$ref %q: more than %d nested references", ref, maxRefDepth))
+		default:
+			vr.refs++
+			vr.validate(target, v, ptr, errs)
+			vr.refs--
+		}
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, v) {
+		fail("expected %s, got %s", typeNames(t), typeOf(v))
+		// Keyword checks below would only repeat the mismatch.
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must be one of %s", compact(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, v) {
+		fail("must be %s", compact(c))
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		vr.validateObject(s, v, ptr, errs)
+	case []any:
+		vr.validateArray(s, v, ptr, errs)
+	case string:
+		n := utf8.RuneCountInString(v)
+		if min, ok := number(s["minLength"]); ok && float64(n) < min {
+			fail("must be at least %v characters", min)
+		}
+		if max, ok := number(s["maxLength"]); ok && float64(n) > max {
+			fail("must be at most %v characters", max)
+		}
+		if p, ok := s["pattern"].(string); ok {
+			if re, err := regexp.Compile(p); err == nil && !re.MatchString(v) {
+				fail("must match pattern %q", p)
+			}
+		}
+	case float64:
+		if min, ok := number(s["minimum"]); ok && v < min {
+			fail("must be >= %v", min)
+		}
+		if max, ok := number(s["maximum"]); ok && v > max {
+			fail("must be <= %v", max)
+		}
+		if min, ok := number(s["exclusiveMinimum"]); ok && v <= min {
+			fail("must be > %v", min)
+		}
+		if max, ok := number(s["exclusiveMaximum"]); ok && v >= max {
+			fail("must be < %v", max)
+		}
+		if m, ok := number(s["multipleOf"]); ok && m > 0 {
+			if q := v / m; q != math.Trunc(q) {
+				fail("must be a multiple of %v", m)
+			}
+		}
+	}
+
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			vr.validate(sub, v, ptr, errs)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		if vr.matching(anyOf, v, ptr) == 0 {
+			fail("must match at least one schema in anyOf")
+		}
+	}
+	if one, ok := s["oneOf"].([]any); ok {
+		if n := vr.matching(one, v, ptr); n != 1 {
+			fail("must match exactly one schema in oneOf, matched %d", n)
+		}
+	}
+	if not, ok := s["not"]; ok {
+		if vr.matching([]any{not}, v, ptr) == 1 {
+			fail("must not match the schema in not")
+		}
+	}
+}
+
+func (vr *validator) validateObject(s map[string]any, obj map[string]any, ptr string, errs *Errors) {
+	if req, ok := s["required"].([]any); ok {
+		for _, r := range req {
+			name, _ := r.(string)
+			if _, ok := obj[name]; !ok {
+				*errs = append(*errs, Error{ptr + "/" + escape(name), "required property is missing"})
+			}
+		}
+	}
+
+	props, _ := s["properties"].(map[string]any)
+	additional, hasAdditional := s["additionalProperties"]
+
+	// Sorted so errors come out in a stable order.
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		child := ptr + "/" + escape(k)
+		if sub, ok := props[k]; ok {
+			vr.validate(sub, obj[k], child, errs)
+			continue
+		}
+		if !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok && !allowed {
+			*errs = append(*errs, Error{child, "property is not allowed"})
+			continue
+		}
+		vr.validate(additional, obj[k], child, errs)
+	}
+}
+
+func (vr *validator) validateArray(s map[string]any, arr []any, ptr string, errs *Errors) {
+	if min, ok := number(s["minItems"]); ok && float64(len(arr)) < min {
+		*errs = append(*errs, Error{ptr, fmt.Sprintf("must have at least %v items", min)})
+	}
+	if max, ok := number(s["maxItems"]); ok && float64(len(arr)) > max {
+		*errs = append(*errs, Error{ptr, fmt.Sprintf("must have at most %v items", max)})
+	}
+	if items, ok := s["items"]; ok {
+		for i, item := range arr {
+			vr.validate(items, item, fmt.Sprintf("%s/%d", ptr, i), errs)
+		}
+	}
+}
+
+// matching counts the schemas v is valid against.
+func (vr *validator) matching(schemas []any, v any, ptr string) int {
+	n := 0
+	for _, sub := range schemas {
+		var errs Errors
+		vr.validate(sub, v, ptr, &errs)
+		if len(errs) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// fail records the first reason the schema cannot be applied.
+func (vr *validator) fail(err error) {
+	if vr.err == nil {
+		vr.err = err
+	}
+}
+
+func matchesType(t, v any) bool {
+	switch t := t.(type) {
+	case string:
+		return isType(t, v)
+	case []any:
+		for _, name := range t {
+			if s, ok := name.(string); ok && isType(s, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(name string, v any) bool {
+	switch name {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	}
+	// Unknown type names are not ours to reject.
+	return true
+}
+
+func typeOf(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func typeNames(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, len(list))
+		for i, n := range list {
+			names[i] = fmt.Sprint(n)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func number(v any) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func compact(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// escape encodes a property name as a JSON pointer token.
+func escape(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,104 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		doc    string
+		want   []string // failing pointers, in order; nil means valid
+	}{
+		{"type", `{"type":"object"}`, `[]`, []string{""}},
+		{"type list", `{"type":["string","null"]}`, `null`, nil},
+		{"integer", `{"type":"integer"}`, `1.5`, []string{""}},
+		{"required", `{"type":"object","required":["path","mode"]}`, `{"path":"a"}`, []string{"/mode"}},
+		{"nested property", `{"properties":{"opts":{"properties":{"depth":{"type":"integer"}}}}}`, `{"opts":{"depth":"deep"}}`, []string{"/opts/depth"}},
+		{"additionalProperties false", `{"properties":{"a":{}},"additionalProperties":false}`, `{"a":1,"b":2,"c":3}`, []string{"/b", "/c"}},
+		{"additionalProperties schema", `{"additionalProperties":{"type":"string"}}`, `{"a":"x","b":2}`, []string{"/b"}},
+		{"escaped pointer", `{"properties":{"a/b~c":{"type":"string"}}}`, `{"a/b~c":1}`, []string{"/a~1b~0c"}},
+		{"enum", `{"enum":["r","w"]}`, `"x"`, []string{""}},
+		{"const", `{"const":3}`, `3`, nil},
+		{"string bounds", `{"minLength":2,"maxLength":3}`, `"ü"`, []string{""}},
+		{"pattern", `{"pattern":"^/"}`, `"rel"`, []string{""}},
+		{"number bounds", `{"minimum":0,"exclusiveMaximum":10,"multipleOf":2}`, `11`, []string{"", ""}},
+		{"items", `{"items":{"type":"string"},"maxItems":2}`, `["a",1,"c"]`, []string{"", "/1"}},
+		{"allOf", `{"allOf":[{"required":["a"]},{"required":["b"]}]}`, `{}`, []string{"/a", "/b"}},
+		{"anyOf", `{"anyOf":[{"type":"string"},{"type":"number"}]}`, `true`, []string{""}},
+		{"oneOf", `{"oneOf":[{"type":"number"},{"type":"integer"}]}`, `1`, []string{""}},
+		{"not", `{"not":{"type":"null"}}`, `null`, []string{""}},
+		{"false schema", `{"properties":{"a":false}}`, `{"a":1}`, []string{"/a"}},
+		{"unknown keyword", `{"format":"uri","x-extra":true}`, `"anything"`, nil},
+		{"$ref to $defs", `{"properties":{"p":{"$ref":"#/$defs/path"}},"$defs":{"path":{"type":"string","pattern":"^/"}}}`, `{"p":"rel"}`, []string{"/p"}},
+		{"$ref to definitions", `{"properties":{"p":{"$ref":"#/definitions/n"}},"definitions":{"n":{"type":"number"}}}`, `{"p":"1"}`, []string{"/p"}},
+		{"$ref with siblings", `{"properties":{"p":{"$ref":"#/$defs/s","maxLength":2}},"$defs":{"s":{"type":"string"}}}`, `{"p":"abc"}`, []string{"/p"}},
+		{"$ref escaped", `{"properties":{"p":{"$ref":"#/$defs/a~1b"}},"$defs":{"a/b":{"type":"string"}}}`, `{"p":1}`, []string{"/p"}},
+		{"$ref into array", `{"properties":{"p":{"$ref":"#/$defs/both/1"}},"$defs":{"both":[{},{"type":"string"}]}}`, `{"p":1}`, []string{"/p"}},
+		{"recursive $ref", `{"type":"object","properties":{"name":{"type":"string"},"children":{"type":"array","items":{"$ref":"#"}}}}`, `{"name":"a","children":[{"name":"b","children":[{"name":3}]}]}`, []string{"/children/0/children/0/name"}},
+		{"valid", `{"type":"object","properties":{"path":{"type":"string"}},"required":["path"],"additionalProperties":false}`, `{"path":"/tmp"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.schema, tt.doc)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("err = %v, want Errors", err)
+			}
+			got := make([]string, len(errs))
+			for i, e := range errs {
+				got[i] = e.Pointer
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pointers = %q, want %q (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidateUnusableSchema(t *testing.T) {
+	tests := []struct {
+		name, schema, want string
+	}{
+		{"missing target", `{"$ref":"#/$defs/nope"}`, "not found"},
+		{"remote", `{"$ref":"https://example.com/schema.json"}`, "only references within the schema"},
+		{"anchor", `{"$ref":"#node"}`, "anchors are not supported"},
+		{"loop", `{"$ref":"#/$defs/a","$defs":{"a":{"$ref":"#/$defs/b"},"b":{"$ref":"#/$defs/a"}}}`, "nested references"},
+		{"bad JSON", `{"type":`, "decode schema"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.schema, `{}`)
+			var errs Errors
+			if err == nil || errors.As(err, &errs) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want a plain error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateGoValues(t *testing.T) {
+	s := map[string]any{"type": "object", "required": []string{"path"}}
+	if err := Validate(s, map[string]any{"path": "a"}); err != nil {
+		t.Errorf("valid map: %v", err)
+	}
+	if err := Validate(s, struct{ Mode int }{1}); err == nil {
+		t.Error("struct without path accepted")
+	}
+	if err := Validate([]byte(`{"type":"string"}`), "not JSON"); err == nil || !strings.Contains(err.Error(), "decode document") {
+		t.Errorf("a Go string is JSON text: err = %v", err)
+	}
+	if got := (Errors{{"", "expected object, got array"}, {"/a", "required property is missing"}}).Error(); got != "invalid arguments: (root): expected object, got array; /a: required property is missing" {
+		t.Errorf("Errors.Error() = %q", got)
+	}
+}
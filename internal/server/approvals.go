@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gomcp-pilot/internal/approval"
+	"gomcp-pilot/internal/auth"
+	"gomcp-pilot/internal/schema"
+	"gomcp-pilot/internal/store"
+)
+
+// approvalKeepAlive is how often an idle event stream sends a comment so
+// proxies do not close it.
+const approvalKeepAlive = 15 * time.Second
+
+func (s *Server) mountApprovals(mux *http.ServeMux) {
+	if s.approvals == nil {
+		return
+	}
+	mux.HandleFunc("GET /approvals", s.handleListApprovals)
+	mux.HandleFunc("GET /approvals/events", s.handleApprovalEvents)
+	mux.HandleFunc("POST /approvals/{id}/{decision}", s.handleResolveApproval)
+	mux.HandleFunc("GET /grants", s.handleListGrants)
+	mux.HandleFunc("DELETE /grants/{id}", s.handleRevokeGrant)
+}
+
+func (s *Server) handleListApprovals(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]any{"approvals": s.approvals.List()})
+}
+
+func (s *Server) handleResolveApproval(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var approve bool
+	switch r.PathValue("decision") {
+	case "approve":
+		approve = true
+	case "deny":
+	default:
+		http.Error(w, "decision must be approve or deny", http.StatusNotFound)
+		return
+	}
+
+	// ?grant=tool|hour|args also remembers the approval for later calls.
+	grant := r.URL.Query().Get("grant")
+	switch grant {
+	case "":
+	case approval.GrantTool, approval.GrantHour, approval.GrantArgs:
+		if !approve {
+			http.Error(w, "grant is only valid when approving", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "grant must be tool, hour or args", http.StatusBadRequest)
+		return
+	}
+
+	// An optional body {"arguments": {...}} approves the call with edited arguments.
+	var body struct {
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "invalid JSON payload", http.StatusBadRequest)
+			return
+		}
+	}
+	edited := len(body.Arguments) > 0
+	if edited && (!approve || grant != "") {
+		http.Error(w, "arguments can only be sent when approving, without a grant", http.StatusBadRequest)
+		return
+	}
+
+	// The audit log names the token that decided.
+	approver := "api"
+	if caller := auth.FromContext(r.Context()).Caller(); caller != "" {
+		approver += ":" + caller
+	}
+	var err error
+	switch {
+	case edited:
+		err = s.approvals.ApproveEdited(id, string(body.Arguments), approver)
+	case grant != "":
+		err = s.approvals.ApproveWithGrant(id, grant, approver)
+	default:
+		err = s.approvals.Resolve(id, approve, approver)
+	}
+	if err != nil {
+		var invalid schema.Errors
+		switch {
+		case errors.Is(err, approval.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, store.ErrNoStore):
+			http.Error(w, "grants are not available without the store", http.StatusServiceUnavailable)
+		case errors.As(err, &invalid):
+			writeJSONStatus(w, http.StatusUnprocessableEntity, map[string]any{"error": err.Error(), "errors": invalid})
+		case edited:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	s.logger.Printf("Approval %s resolved via HTTP (approved=%v grant=%q edited=%v)", id, approve, grant, edited)
+	writeJSON(w, map[string]any{"id": id, "approved": approve, "grant": grant, "edited": edited})
+}
+
+func (s *Server) handleListGrants(w http.ResponseWriter, _ *http.Request) {
+	grants, err := store.ListGrants()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{"grants": grants})
+}
+
+func (s *Server) handleRevokeGrant(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid grant id", http.StatusBadRequest)
+		return
+	}
+	found, err := store.RevokeGrant(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "grant not found", http.StatusNotFound)
+		return
+	}
+	s.logger.Printf("Grant %d revoked via HTTP", id)
+	writeJSON(w, map[string]any{"id": id, "revoked": true})
+}
+
+// handleApprovalEvents streams queue events (pending, approved, denied,
+// withdrawn) as Server-Sent Events until the client disconnects.
+func (s *Server) handleApprovalEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := s.approvals.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(approvalKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case ev := <-events:
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+		}
+		flusher.Flush()
+	}
+}
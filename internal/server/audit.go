@@ -0,0 +1,171 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gomcp-pilot/internal/auth"
+	"gomcp-pilot/internal/store"
+)
+
+// mountAudit loads the checkpoint key up front, so a bad path fails at start
+// rather than on the first checkpoint.
+func (s *Server) mountAudit(mux *http.ServeMux) error {
+	if path := s.cfg.Store.CheckpointKey; path != "" {
+		key, err := store.LoadSigningKey(path)
+		if err != nil {
+			return fmt.Errorf("load checkpoint_key: %w", err)
+		}
+		s.checkpointKey = key
+	}
+	mux.HandleFunc("GET /audit/calls", s.handleListCalls)
+	mux.HandleFunc("DELETE /audit/calls", s.handlePruneCalls)
+	mux.HandleFunc("GET /audit/verify", s.handleVerifyChain)
+	mux.HandleFunc("GET /audit/checkpoint", s.handleCheckpoint)
+	return nil
+}
+
+// handleListCalls serves the audit log, newest first. Filters: operation,
+// upstream, tool, status, caller, since/until (RFC 3339 or a duration before
+// now, e.g. "24h"), q (substring of the arguments); pages via limit and cursor.
+func (s *Server) handleListCalls(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := store.CallFilter{
+		Operation: q.Get("operation"),
+		Upstream:  q.Get("upstream"),
+		Tool:      q.Get("tool"),
+		Status:    q.Get("status"),
+		Caller:    q.Get("caller"),
+		Search:    q.Get("q"),
+	}
+	switch f.Status {
+	case "", store.StatusSuccess, store.StatusError, store.StatusCancelled, store.StatusTimeout:
+	default:
+		http.Error(w, "status must be success, error, cancelled or timeout", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	now := time.Now()
+	if f.Since, err = store.ParseTime(q.Get("since"), now); err != nil {
+		http.Error(w, "since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f.Until, err = store.ParseTime(q.Get("until"), now); err != nil {
+		http.Error(w, "until: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit <= 0 || f.Limit > store.MaxQueryLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", store.MaxQueryLimit), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("cursor"); v != "" {
+		if f.Cursor, err = strconv.ParseInt(v, 10, 64); err != nil || f.Cursor <= 0 {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	calls, next, err := store.QueryCalls(f)
+	if errors.Is(err, store.ErrNoStore) {
+		http.Error(w, "audit log is not available in this mode", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]any{"calls": calls}
+	if next > 0 {
+		resp["next_cursor"] = strconv.FormatInt(next, 10)
+	}
+	writeJSON(w, resp)
+}
+
+// handlePruneCalls deletes the records older than before (RFC 3339 or a
+// duration before now, e.g. "720h").
+func (s *Server) handlePruneCalls(w http.ResponseWriter, r *http.Request) {
+	before, err := store.ParseTime(r.URL.Query().Get("before"), time.Now())
+	if err != nil {
+		http.Error(w, "before: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if before.IsZero() {
+		http.Error(w, "before is required", http.StatusBadRequest)
+		return
+	}
+	deleted, err := store.Prune(before, 0, auth.FromContext(r.Context()).Caller())
+	if errors.Is(err, store.ErrNoStore) {
+		http.Error(w, "audit log is not available in this mode", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.logger.Printf("Pruned %d audit records before %s", deleted, before.UTC().Format(time.RFC3339))
+	writeJSON(w, map[string]any{"deleted": deleted})
+}
+
+// handleVerifyChain walks the audit log's hash chain and reports the first
+// broken link, if any. Given id and hash from a checkpoint, it also reports
+// whether that record still has that hash, when the chain is intact.
+func (s *Server) handleVerifyChain(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var checkpointID int64
+	if v := q.Get("id"); v != "" {
+		var err error
+		if checkpointID, err = strconv.ParseInt(v, 10, 64); err != nil || checkpointID <= 0 || q.Get("hash") == "" {
+			http.Error(w, "id must be a record ID and come with hash", http.StatusBadRequest)
+			return
+		}
+	}
+
+	st, err := store.VerifyChain()
+	if errors.Is(err, store.ErrNoStore) {
+		http.Error(w, "audit log is not available in this mode", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if st.Broken != nil {
+		s.logger.Printf("Audit chain broken at record %d: %s", st.Broken.ID, st.Broken.Reason)
+	}
+	resp := map[string]any{"chain": st}
+	if checkpointID > 0 && st.Broken == nil {
+		result, err := store.CompareCheckpoint(st, checkpointID, q.Get("hash"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp["checkpoint"] = result
+	}
+	writeJSON(w, resp)
+}
+
+// handleCheckpoint signs a checkpoint of the audit log's newest record with
+// store.checkpoint_key.
+func (s *Server) handleCheckpoint(w http.ResponseWriter, r *http.Request) {
+	if s.checkpointKey == nil {
+		http.Error(w, "checkpoints need store.checkpoint_key in the config", http.StatusNotFound)
+		return
+	}
+	cp, err := store.NewCheckpoint(s.checkpointKey)
+	if errors.Is(err, store.ErrNoStore) {
+		http.Error(w, "audit log is not available in this mode", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	s.logger.Printf("Signed audit checkpoint at record %d", cp.Checkpoint.LastID)
+	writeJSON(w, cp)
+}
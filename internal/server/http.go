@@ -2,14 +2,26 @@ package server
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
-	"time"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync/atomic"
 
+	"gomcp-pilot/internal/approval"
+	"gomcp-pilot/internal/auth"
 	"gomcp-pilot/internal/config"
 	"gomcp-pilot/internal/process"
+	"gomcp-pilot/internal/schema"
 
 	mcpserver "github.com/mark3labs/mcp-go/server"
 )
@@ -20,53 +32,204 @@ type Server struct {
 	manager   *process.Manager
 	logger    *log.Logger
 	mcpServer *mcpserver.MCPServer
+	approvals *approval.Queue
+	auth      atomic.Pointer[auth.Authenticator]
+	// checkpointKey signs audit checkpoints; nil when store.checkpoint_key is unset.
+	checkpointKey ed25519.PrivateKey
 }
 
 func New(cfg *config.Config, manager *process.Manager, logger *log.Logger, mcpServer *mcpserver.MCPServer) *Server {
-	return &Server{cfg: cfg, manager: manager, logger: logger, mcpServer: mcpServer}
+	s := &Server{cfg: cfg, manager: manager, logger: logger, mcpServer: mcpServer}
+	s.auth.Store(auth.New(cfg))
+	return s
+}
+
+// ReloadTokens swaps in the auth_token, tokens and JWT settings of a reloaded
+// config, so revoking a token takes effect without a restart.
+func (s *Server) ReloadTokens(cfg *config.Config) {
+	s.auth.Store(s.auth.Load().Reload(cfg))
+}
+
+// SetApprovals exposes q through the /approvals admin endpoints.
+func (s *Server) SetApprovals(q *approval.Queue) {
+	s.approvals = q
 }
 
 // Start runs the HTTP server until the context is cancelled.
 func (s *Server) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", s.handleHealth)
-	mux.HandleFunc("/tools/list", s.handleListTools)
-	mux.HandleFunc("/tools/call", s.handleCallTool)
-	mux.HandleFunc("/resources/list", s.handleListResources)
-	mux.HandleFunc("/resources/read", s.handleReadResource)
+	mux.HandleFunc("/tools/list", rest(s.handleListTools))
+	mux.HandleFunc("/tools/call", rest(s.handleCallTool))
+	mux.HandleFunc("/resources/list", rest(s.handleListResources))
+	mux.HandleFunc("/resources/templates/list", rest(s.handleListResourceTemplates))
+	mux.HandleFunc("/resources/read", rest(s.handleReadResource))
+	mux.HandleFunc("/prompts/list", rest(s.handleListPrompts))
+	mux.HandleFunc("/prompts/get", rest(s.handleGetPrompt))
+	s.mountApprovals(mux)
+	if err := s.mountAudit(mux); err != nil {
+		return err
+	}
 
 	// Add SSE support
 	if s.mcpServer != nil {
-		// The endpoint URL that clients should post messages to.
-		// Constructing it based on config port.
-		endpointURL := fmt.Sprintf("http://localhost:%d/mcp/message", s.cfg.Port)
-		sseServer := mcpserver.NewSSEServer(
+		sseServer := mcpserver.NewSSEServer(s.mcpServer, s.sseOptions()...)
+		mux.Handle("/sse", sseServer.SSEHandler())
+		mux.Handle("/mcp/message", s.precheckMCP(sseServer.MessageHandler(), process.TransportSSE,
+			func(r *http.Request) string { return r.URL.Query().Get("sessionId") },
+			replyOnStream(sseServer)))
+		s.logger.Printf("SSE endpoint mounted at /sse (message endpoint: %s/mcp/message)", s.cfg.PublicBaseURL)
+
+		// Streamable HTTP: single endpoint, sessions tracked via the Mcp-Session-Id header.
+		streamableServer := mcpserver.NewStreamableHTTPServer(
 			s.mcpServer,
-			mcpserver.WithMessageEndpoint(endpointURL),
+			mcpserver.WithEndpointPath("/mcp"),
+			mcpserver.WithStateful(true),
+			mcpserver.WithHTTPContextFunc(func(ctx context.Context, _ *http.Request) context.Context {
+				return process.WithTransport(ctx, process.TransportStreamableHTTP)
+			}),
 		)
+		mux.Handle("/mcp", s.precheckMCP(streamableServer, process.TransportStreamableHTTP,
+			func(r *http.Request) string { return r.Header.Get(mcpserver.HeaderKeySessionID) },
+			replyInBody))
+		s.logger.Printf("Streamable HTTP endpoint mounted at /mcp")
+	}
 
-		mux.Handle("/sse", sseServer.SSEHandler())
-		mux.Handle("/mcp/message", sseServer.MessageHandler())
-		s.logger.Printf("SSE endpoint mounted at /sse (message endpoint: %s)", endpointURL)
+	return s.listen(ctx, mux)
+}
+
+// StartAdmin serves only the health, approval and audit endpoints. It is used in
+// stdio mode, where MCP traffic does not go through HTTP.
+func (s *Server) StartAdmin(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", s.handleHealth)
+	s.mountApprovals(mux)
+	if err := s.mountAudit(mux); err != nil {
+		return err
+	}
+	return s.listen(ctx, mux)
+}
+
+// sseOptions decide the message endpoint the SSE handshake advertises. With
+// public_base_url it is an absolute URL; otherwise it is a path, which clients
+// resolve against the URL they reached /sse at, so it stays correct behind
+// proxies, other hostnames and port mappings.
+func (s *Server) sseOptions() []mcpserver.SSEOption {
+	opts := []mcpserver.SSEOption{
+		mcpserver.WithMessageEndpoint("/mcp/message"),
+		mcpserver.WithSSEContextFunc(func(ctx context.Context, _ *http.Request) context.Context {
+			return process.WithTransport(ctx, process.TransportSSE)
+		}),
+	}
+	if s.cfg.PublicBaseURL != "" {
+		return append(opts, mcpserver.WithBaseURL(s.cfg.PublicBaseURL))
 	}
+	opts = append(opts, mcpserver.WithUseFullURLForMessageEndpoint(false))
+	if s.cfg.TrustForwardedHeaders {
+		opts = append(opts, mcpserver.WithDynamicBasePath(func(r *http.Request, _ string) string {
+			return forwardedPrefix(r)
+		}))
+	}
+	return opts
+}
+
+// forwardedPrefix returns the path a reverse proxy mounts the gateway under,
+// from X-Forwarded-Prefix. Values that are not a plain absolute path are ignored.
+func forwardedPrefix(r *http.Request) string {
+	prefix := strings.TrimSuffix(r.Header.Get("X-Forwarded-Prefix"), "/")
+	if prefix == "" || !strings.HasPrefix(prefix, "/") || strings.HasPrefix(prefix, "//") ||
+		strings.ContainsAny(prefix, "?#\\") || path.Clean(prefix) != prefix {
+		return "/"
+	}
+	return prefix
+}
 
+func (s *Server) listen(ctx context.Context, mux *http.ServeMux) error {
+	network, addr := s.cfg.Listen()
 	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.cfg.Port),
+		Addr:    addr,
 		Handler: s.corsMiddleware(s.authMiddleware(mux)),
 	}
 
+	if s.cfg.TLS() {
+		tlsCfg, err := s.tlsConfig()
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = tlsCfg
+	}
+
+	if network == "unix" {
+		// A socket left behind by a previous run would make Listen fail.
+		if fi, err := os.Stat(addr); err == nil && fi.Mode().Type() == os.ModeSocket {
+			_ = os.Remove(addr)
+		}
+	}
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+
 	go func() {
 		<-ctx.Done()
 		_ = srv.Shutdown(context.Background())
 	}()
 
-	s.logger.Printf("HTTP listening on %s", srv.Addr)
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	where := addr
+	if network == "unix" {
+		where = config.UnixPrefix + addr
+	}
+	if s.cfg.TLS() {
+		s.logger.Printf("HTTPS listening on %s (client certificates: %v)", where, s.cfg.ClientCA != "")
+		err = srv.ServeTLS(ln, s.cfg.TLSCert, s.cfg.TLSKey)
+	} else {
+		s.logger.Printf("HTTP listening on %s", where)
+		err = srv.Serve(ln)
+	}
+	if err != nil && err != http.ErrServerClosed {
 		return err
 	}
 	return nil
 }
 
+// tlsConfig loads the server key pair up front, so a bad path fails at start
+// rather than on the first handshake, and the client CA pool for mutual TLS.
+// Client certificates are optional: callers without one use bearer tokens.
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	if _, err := tls.LoadX509KeyPair(s.cfg.TLSCert, s.cfg.TLSKey); err != nil {
+		return nil, fmt.Errorf("load tls_cert/tls_key: %w", err)
+	}
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.cfg.ClientCA != "" {
+		pem, err := os.ReadFile(s.cfg.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("read client_ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("client_ca %s contains no PEM certificates", s.cfg.ClientCA)
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsCfg, nil
+}
+
+// rest marks requests to the REST endpoints for the audit log and gives them a
+// request ID. A client-supplied X-Request-Id is kept so its logs and ours
+// correlate; either way it is echoed in the response.
+func rest(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-Id")
+		if requestID == "" || len(requestID) > 128 {
+			requestID = process.NewRequestID()
+		}
+		w.Header().Set("X-Request-Id", requestID)
+		ctx := process.WithTransport(process.WithRequestID(r.Context(), requestID), process.TransportREST)
+		h(w, r.WithContext(ctx))
+	}
+}
+
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte("ok"))
@@ -76,9 +239,13 @@ func (s *Server) handleListTools(w http.ResponseWriter, r *http.Request) {
 	upstream := r.URL.Query().Get("upstream")
 	tools, err := s.manager.ListTools(upstream)
 	if err != nil {
+		s.manager.RecordList(r.Context(), process.OpListTools, upstream, 0, err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	id := auth.FromContext(r.Context())
+	tools = slices.DeleteFunc(tools, func(t process.ToolDescriptor) bool { return !id.AllowsTool(t.Upstream, t.Name) })
+	s.manager.RecordList(r.Context(), process.OpListTools, upstream, len(tools), nil)
 	writeJSON(w, tools)
 }
 
@@ -86,12 +253,30 @@ func (s *Server) handleListResources(w http.ResponseWriter, r *http.Request) {
 	upstream := r.URL.Query().Get("upstream")
 	resources, err := s.manager.ListResources(upstream)
 	if err != nil {
+		s.manager.RecordList(r.Context(), process.OpListResources, upstream, 0, err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	id := auth.FromContext(r.Context())
+	resources = slices.DeleteFunc(resources, func(d process.ResourceDescriptor) bool { return !id.AllowsUpstream(d.Upstream) })
+	s.manager.RecordList(r.Context(), process.OpListResources, upstream, len(resources), nil)
 	writeJSON(w, map[string]interface{}{"resources": resources})
 }
 
+func (s *Server) handleListResourceTemplates(w http.ResponseWriter, r *http.Request) {
+	upstream := r.URL.Query().Get("upstream")
+	templates, err := s.manager.ListResourceTemplates(upstream)
+	if err != nil {
+		s.manager.RecordList(r.Context(), process.OpListResourceTemplates, upstream, 0, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id := auth.FromContext(r.Context())
+	templates = slices.DeleteFunc(templates, func(d process.ResourceTemplateDescriptor) bool { return !id.AllowsUpstream(d.Upstream) })
+	s.manager.RecordList(r.Context(), process.OpListResourceTemplates, upstream, len(templates), nil)
+	writeJSON(w, map[string]interface{}{"resourceTemplates": templates})
+}
+
 func (s *Server) handleReadResource(w http.ResponseWriter, r *http.Request) {
 	uri := r.URL.Query().Get("uri")
 	if uri == "" {
@@ -101,7 +286,59 @@ func (s *Server) handleReadResource(w http.ResponseWriter, r *http.Request) {
 
 	res, err := s.manager.ReadResource(r.Context(), uri)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		code := http.StatusNotFound
+		if errors.Is(err, auth.ErrForbidden) {
+			code = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	writeJSON(w, res)
+}
+
+func (s *Server) handleListPrompts(w http.ResponseWriter, r *http.Request) {
+	upstream := r.URL.Query().Get("upstream")
+	prompts, err := s.manager.ListPrompts(upstream)
+	if err != nil {
+		s.manager.RecordList(r.Context(), process.OpListPrompts, upstream, 0, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id := auth.FromContext(r.Context())
+	prompts = slices.DeleteFunc(prompts, func(d process.PromptDescriptor) bool { return !id.AllowsUpstream(d.Upstream) })
+	s.manager.RecordList(r.Context(), process.OpListPrompts, upstream, len(prompts), nil)
+	writeJSON(w, map[string]interface{}{"prompts": prompts})
+}
+
+type promptPayload struct {
+	Upstream  string            `json:"upstream"`
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+func (s *Server) handleGetPrompt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload promptPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	if payload.Upstream == "" || payload.Name == "" {
+		http.Error(w, "upstream and name are required", http.StatusBadRequest)
+		return
+	}
+
+	res, err := s.manager.GetPrompt(r.Context(), payload.Upstream, payload.Name, payload.Arguments)
+	if err != nil {
+		code := http.StatusBadGateway
+		if errors.Is(err, auth.ErrForbidden) {
+			code = http.StatusForbidden
+		}
+		http.Error(w, fmt.Sprintf("get prompt failed: %v", err), code)
 		return
 	}
 	writeJSON(w, res)
@@ -129,16 +366,26 @@ func (s *Server) handleCallTool(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 90*time.Second)
-	defer cancel()
-
-	result, err := s.manager.CallTool(ctx, process.CallRequest{
+	// The manager applies the upstream's call timeout; r.Context() ends if the client disconnects.
+	result, err := s.manager.CallTool(r.Context(), process.CallRequest{
 		Upstream:  payload.Upstream,
 		Tool:      payload.Tool,
 		Arguments: payload.Arguments,
 	})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("call failed: %v", err), http.StatusBadGateway)
+		var invalid schema.Errors
+		if errors.As(err, &invalid) {
+			writeJSONStatus(w, http.StatusUnprocessableEntity, map[string]any{"error": err.Error(), "errors": invalid})
+			return
+		}
+		code := http.StatusBadGateway
+		switch {
+		case errors.Is(err, auth.ErrForbidden):
+			code = http.StatusForbidden
+		case errors.Is(err, context.DeadlineExceeded):
+			code = http.StatusGatewayTimeout
+		}
+		http.Error(w, fmt.Sprintf("call failed: %v", err), code)
 		return
 	}
 
@@ -152,8 +399,9 @@ func (s *Server) handleCallTool(w http.ResponseWriter, r *http.Request) {
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Mcp-Session-Id, Mcp-Protocol-Version, Last-Event-ID, X-Request-Id")
+		w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id, X-Request-Id")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
@@ -164,30 +412,69 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// authMiddleware resolves the client certificate or bearer token to an identity, checks it holds
+// the scope the endpoint needs, and stores it in the request context for the
+// handlers and the manager to check upstream and tool permissions.
 func (s *Server) authMiddleware(next http.Handler) http.Handler {
-	if s.cfg.AuthToken == "" {
-		return next
-	}
-	expected := "Bearer " + s.cfg.AuthToken
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		auth := r.Header.Get("Authorization")
-		if auth == "" {
-			token := r.URL.Query().Get("access_token")
-			if token != "" {
-				auth = "Bearer " + token
-			}
+		authn := s.auth.Load()
+		if !authn.Enabled() {
+			next.ServeHTTP(w, r)
+			return
 		}
 
-		if auth != expected {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		var id *auth.Identity
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			// A client certificate verified against client_ca.
+			id = authn.AuthenticateCert(r.TLS.VerifiedChains[0][0])
+		}
+		if id == nil {
+			secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				// EventSource cannot set headers, so the dashboard passes the token in the query.
+				secret = r.URL.Query().Get("access_token")
+			}
+			var err error
+			if id, err = authn.Authenticate(secret); err != nil {
+				if errors.Is(err, auth.ErrInvalidJWT) {
+					// Only JWT rejections carry a reason worth logging.
+					s.logger.Printf("Rejected bearer token for %s %s: %v", r.Method, r.URL.Path, err)
+				}
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		if scope := scopeFor(r.URL.Path); scope != "" && !id.Can(scope) {
+			http.Error(w, fmt.Sprintf("token %s lacks the %s scope", id.Name, scope), http.StatusForbidden)
 			return
 		}
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), id)))
 	})
 }
 
+// scopeFor returns the token scope an endpoint requires. MCP endpoints need
+// none up front; each MCP request is checked as it is handled.
+func scopeFor(path string) string {
+	switch {
+	case path == "/tools/list", path == "/resources/list", path == "/resources/templates/list", path == "/prompts/list":
+		return config.ScopeList
+	case path == "/tools/call":
+		return config.ScopeCall
+	case path == "/resources/read", path == "/prompts/get":
+		return config.ScopeRead
+	case strings.HasPrefix(path, "/approvals"), strings.HasPrefix(path, "/grants"), strings.HasPrefix(path, "/audit"):
+		return config.ScopeAdmin
+	}
+	return ""
+}
+
 func writeJSON(w http.ResponseWriter, v any) {
+	writeJSONStatus(w, http.StatusOK, v)
+}
+
+func writeJSONStatus(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(v); err != nil {
 		log.Printf("write json: %v", err)
 	}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gomcp-pilot/internal/auth"
+	"gomcp-pilot/internal/config"
+)
+
+func TestScopeFor(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"/tools/list", config.ScopeList},
+		{"/resources/list", config.ScopeList},
+		{"/resources/templates/list", config.ScopeList},
+		{"/prompts/list", config.ScopeList},
+		{"/tools/call", config.ScopeCall},
+		{"/resources/read", config.ScopeRead},
+		{"/prompts/get", config.ScopeRead},
+		{"/approvals", config.ScopeAdmin},
+		{"/approvals/3/approve", config.ScopeAdmin},
+		{"/grants/1", config.ScopeAdmin},
+		{"/audit/calls", config.ScopeAdmin},
+		{"/audit/checkpoint", config.ScopeAdmin},
+		{"/health", ""},
+		{"/mcp", ""},
+		{"/sse", ""},
+		{"/tools/call/extra", ""},
+	}
+	for _, tt := range tests {
+		if got := scopeFor(tt.path); got != tt.want {
+			t.Errorf("scopeFor(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	cfg := &config.Config{Tokens: []config.Token{
+		{Name: "lister", SecretHash: auth.HashSecret("list-secret"), Scopes: []string{config.ScopeList}},
+		{Name: "admin", SecretHash: auth.HashSecret("admin-secret"), Scopes: []string{config.ScopeAdmin}},
+	}}
+	s := New(cfg, nil, log.New(io.Discard, "", 0), nil)
+	var caller string
+	h := s.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller = auth.FromContext(r.Context()).Caller()
+	}))
+
+	tests := []struct {
+		name, target, header string
+		wantStatus           int
+		wantCaller           string
+	}{
+		{"no token", "/tools/list", "", http.StatusUnauthorized, ""},
+		{"unknown token", "/tools/list", "Bearer nope", http.StatusUnauthorized, ""},
+		{"not a bearer token", "/tools/list", "Basic list-secret", http.StatusUnauthorized, ""},
+		{"scope held", "/tools/list", "Bearer list-secret", http.StatusOK, "lister"},
+		{"scope missing", "/tools/call", "Bearer list-secret", http.StatusForbidden, ""},
+		{"admin scope missing", "/audit/calls", "Bearer list-secret", http.StatusForbidden, ""},
+		{"admin scope held", "/audit/calls", "Bearer admin-secret", http.StatusOK, "admin"},
+		{"admin lacks list", "/tools/list", "Bearer admin-secret", http.StatusForbidden, ""},
+		{"no scope needed", "/mcp", "Bearer admin-secret", http.StatusOK, "admin"},
+		{"no scope needed without token", "/mcp", "", http.StatusUnauthorized, ""},
+		{"query token", "/tools/list?access_token=list-secret", "", http.StatusOK, "lister"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caller = ""
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus || caller != tt.wantCaller {
+				t.Errorf("status %d, caller %q; want %d, %q", w.Code, caller, tt.wantStatus, tt.wantCaller)
+			}
+		})
+	}
+
+	// Without credentials configured the API is open.
+	open := New(&config.Config{}, nil, log.New(io.Discard, "", 0), nil)
+	w := httptest.NewRecorder()
+	open.authMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit/calls", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("open API: status %d", w.Code)
+	}
+}
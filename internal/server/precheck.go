@@ -0,0 +1,61 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+
+	"gomcp-pilot/internal/mcpbridge"
+	"gomcp-pilot/internal/process"
+)
+
+// replyFunc delivers a precheck error to the client. It reports false if it
+// could not, in which case the message goes to the MCP handler after all.
+type replyFunc func(w http.ResponseWriter, r *http.Request, resp *mcp.JSONRPCError) bool
+
+// precheckMCP answers MCP messages that mcpbridge.Precheck rejects before next
+// sees them. sessionID extracts the MCP session the message belongs to.
+func (s *Server) precheckMCP(next http.Handler, transport string, sessionID func(*http.Request) string, reply replyFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "read body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := process.WithTransport(r.Context(), transport)
+		if id := sessionID(r); id != "" {
+			ctx = process.WithSessionID(ctx, id)
+		}
+		if resp := mcpbridge.Precheck(ctx, s.manager, body); resp != nil && reply(w, r, resp) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// replyInBody answers a Streamable HTTP POST with the error as its JSON body.
+func replyInBody(w http.ResponseWriter, _ *http.Request, resp *mcp.JSONRPCError) bool {
+	writeJSON(w, resp)
+	return true
+}
+
+// replyOnStream queues the error on the SSE session's event stream, where the
+// SSE transport sends every response, and accepts the POST.
+func replyOnStream(sse *mcpserver.SSEServer) replyFunc {
+	return func(w http.ResponseWriter, r *http.Request, resp *mcp.JSONRPCError) bool {
+		if err := sse.SendEventToSession(r.URL.Query().Get("sessionId"), resp); err != nil {
+			return false
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return true
+	}
+}
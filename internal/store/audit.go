@@ -0,0 +1,164 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Bounds on CallFilter.Limit.
+const (
+	DefaultQueryLimit = 50
+	MaxQueryLimit     = 1000
+)
+
+// CallFilter selects audit records. Zero fields do not filter.
+type CallFilter struct {
+	Operation string
+	Upstream  string
+	Tool      string
+	Status    string
+	Caller    string
+	Since     time.Time // inclusive
+	Until     time.Time // exclusive
+	// Search matches a substring of the arguments the client sent or the
+	// arguments a reviewer approved.
+	Search string
+	// Cursor continues a previous query: only records older than it are
+	// returned. Pass the next cursor QueryCalls returned.
+	Cursor int64
+	Limit  int
+}
+
+// ParseTime parses a time bound of a CallFilter or a prune: an RFC 3339 time,
+// or a duration such as "24h" counted back from now. An empty v is the zero
+// time, which does not filter.
+func ParseTime(v string, now time.Time) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		if d < 0 {
+			return time.Time{}, errors.New("duration must not be negative")
+		}
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, errors.New("expected an RFC 3339 time or a duration such as 24h")
+	}
+	return t, nil
+}
+
+// QueryCalls returns the records matching f, newest first, and the cursor for
+// the next page, or 0 when there are no more records.
+func QueryCalls(f CallFilter) ([]CallRecord, int64, error) {
+	if DB == nil {
+		return nil, 0, ErrNoStore
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	limit = min(limit, MaxQueryLimit)
+
+	var where []string
+	var args []any
+	eq := func(col, v string) {
+		if v != "" {
+			where = append(where, col+" = ?")
+			args = append(args, v)
+		}
+	}
+	eq("operation", f.Operation)
+	eq("upstream", f.Upstream)
+	eq("tool", f.Tool)
+	eq("status", f.Status)
+	eq("caller", f.Caller)
+	if !f.Since.IsZero() {
+		where = append(where, "timestamp >= ?")
+		args = append(args, f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "timestamp < ?")
+		args = append(args, f.Until.UTC())
+	}
+	if f.Search != "" {
+		where = append(where, `(arguments LIKE ? ESCAPE '\' OR approved_arguments LIKE ? ESCAPE '\')`)
+		pattern := "%" + escapeLike(f.Search) + "%"
+		args = append(args, pattern, pattern)
+	}
+	if f.Cursor > 0 {
+		where = append(where, "id < ?")
+		args = append(args, f.Cursor)
+	}
+
+	query := `SELECT ` + callColumns + ` FROM request_logs`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	// IDs grow with insertion, so they order records like their timestamps
+	// and make a stable cursor. One extra row tells whether a next page exists.
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	records := []CallRecord{}
+	for rows.Next() {
+		r, err := scanCall(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	var next int64
+	if len(records) > limit {
+		records = records[:limit]
+		next = records[limit-1].ID
+	}
+	return records, next, nil
+}
+
+// callColumns are the request_logs columns scanCall reads, in order.
+const callColumns = `id, timestamp, operation, upstream, tool, arguments, status, error, duration_ms, rule, approved_arguments, caller,
+	result, is_error, transport, session_id, decision, approver, request_id, prev_hash, hash`
+
+func scanCall(rows *sql.Rows) (CallRecord, error) {
+	var r CallRecord
+	var args, errStr, rule, approved, caller sql.NullString
+	var result, transport, session, decision, approver, requestID, prevHash, hash sql.NullString
+	var isError sql.NullBool
+	if err := rows.Scan(&r.ID, &r.Timestamp, &r.Operation, &r.Upstream, &r.Tool, &args, &r.Status, &errStr, &r.DurationMs, &rule, &approved, &caller,
+		&result, &isError, &transport, &session, &decision, &approver, &requestID, &prevHash, &hash); err != nil {
+		return r, err
+	}
+	r.Arguments = args.String
+	r.Error = errStr.String
+	r.Rule = rule.String
+	r.ApprovedArguments = approved.String
+	r.Caller = caller.String
+	r.Result = result.String
+	r.IsError = isError.Bool
+	r.Transport = transport.String
+	r.SessionID = session.String
+	r.Decision = decision.String
+	r.Approver = approver.String
+	r.RequestID = requestID.String
+	r.PrevHash = prevHash.String
+	r.Hash = hash.String
+	return r, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s for use with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// seedCalls records calls with the given fields, oldest first, dated an hour
+// apart ending an hour before now, and returns their IDs.
+func seedCalls(t *testing.T, recs ...CallRecord) []int64 {
+	t.Helper()
+	var ids []int64
+	for i, r := range recs {
+		if r.Operation == "" {
+			r.Operation = "tools/call"
+		}
+		if err := RecordCall(r); err != nil {
+			t.Fatal(err)
+		}
+		var id int64
+		if err := DB.QueryRow(`SELECT MAX(id) FROM request_logs`).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		at := time.Now().UTC().Add(-time.Duration(len(recs)-i) * time.Hour)
+		if _, err := DB.Exec(`UPDATE request_logs SET timestamp = ? WHERE id = ?`, at, id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func queryIDs(t *testing.T, f CallFilter) ([]int64, int64) {
+	t.Helper()
+	calls, next, err := QueryCalls(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []int64{}
+	for _, c := range calls {
+		ids = append(ids, c.ID)
+	}
+	return ids, next
+}
+
+func TestQueryCallsFilters(t *testing.T) {
+	openMemory(t)
+	ids := seedCalls(t,
+		CallRecord{Upstream: "fs", Tool: "read", Arguments: `{"path":"/etc/hosts"}`, Status: StatusSuccess, Caller: "ci"},
+		CallRecord{Upstream: "fs", Tool: "write", Arguments: `{"path":"/tmp/a"}`, ApprovedArguments: `{"path":"/tmp/b"}`, Status: StatusError},
+		CallRecord{Upstream: "git", Tool: "log", Arguments: `{}`, Status: StatusTimeout, Caller: "ci"},
+		CallRecord{Operation: "resources/read", Upstream: "fs", Tool: "file:///etc/hosts", Status: StatusSuccess},
+	)
+	now := time.Now()
+	tests := []struct {
+		name   string
+		filter CallFilter
+		want   []int64
+	}{
+		{"none", CallFilter{}, []int64{ids[3], ids[2], ids[1], ids[0]}},
+		{"operation", CallFilter{Operation: "resources/read"}, []int64{ids[3]}},
+		{"upstream", CallFilter{Upstream: "fs"}, []int64{ids[3], ids[1], ids[0]}},
+		{"tool", CallFilter{Tool: "write"}, []int64{ids[1]}},
+		{"status", CallFilter{Status: StatusTimeout}, []int64{ids[2]}},
+		{"caller", CallFilter{Caller: "ci"}, []int64{ids[2], ids[0]}},
+		{"combined", CallFilter{Upstream: "fs", Caller: "ci"}, []int64{ids[0]}},
+		{"since", CallFilter{Since: now.Add(-150 * time.Minute)}, []int64{ids[3], ids[2]}},
+		{"until", CallFilter{Until: now.Add(-150 * time.Minute)}, []int64{ids[1], ids[0]}},
+		{"window", CallFilter{Since: now.Add(-210 * time.Minute), Until: now.Add(-90 * time.Minute)}, []int64{ids[2], ids[1]}},
+		{"search arguments", CallFilter{Search: "/etc"}, []int64{ids[0]}},
+		{"search approved arguments", CallFilter{Search: "/tmp/b"}, []int64{ids[1]}},
+		{"no match", CallFilter{Upstream: "db"}, []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := queryIDs(t, tt.filter); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryCallsPages(t *testing.T) {
+	openMemory(t)
+	ids := recordN(t, 5)
+
+	var got []int64
+	var cursor int64
+	pages := 0
+	for {
+		page, next := queryIDs(t, CallFilter{Upstream: "fs", Limit: 2, Cursor: cursor})
+		got = append(got, page...)
+		pages++
+		if next == 0 {
+			break
+		}
+		if next != page[len(page)-1] {
+			t.Fatalf("next cursor %d, want the last ID of the page %v", next, page)
+		}
+		cursor = next
+	}
+	want := []int64{ids[4], ids[3], ids[2], ids[1], ids[0]}
+	if pages != 3 || !slices.Equal(got, want) {
+		t.Errorf("%d pages of %v, want 3 pages of %v", pages, got, want)
+	}
+
+	// A page ending exactly at the last record has no next cursor.
+	if page, next := queryIDs(t, CallFilter{Limit: 5}); len(page) != 5 || next != 0 {
+		t.Errorf("full page: %v, next %d", page, next)
+	}
+	// Records added meanwhile do not shift later pages.
+	first, next := queryIDs(t, CallFilter{Limit: 2})
+	recordN(t, 1)
+	if second, _ := queryIDs(t, CallFilter{Limit: 2, Cursor: next}); first[1] != ids[3] || !slices.Equal(second, []int64{ids[2], ids[1]}) {
+		t.Errorf("pages %v then %v", first, second)
+	}
+	// The limit is capped.
+	if calls, _, err := QueryCalls(CallFilter{Limit: MaxQueryLimit + 1}); err != nil || len(calls) != 6 {
+		t.Errorf("over the cap: %d calls, %v", len(calls), err)
+	}
+}
+
+func TestQueryCallsSearchEscapesWildcards(t *testing.T) {
+	openMemory(t)
+	ids := seedCalls(t,
+		CallRecord{Upstream: "db", Tool: "query", Arguments: `{"sql":"LIKE 'a%'"}`},
+		CallRecord{Upstream: "db", Tool: "query", Arguments: `{"sql":"LIKE 'ab'"}`},
+		CallRecord{Upstream: "fs", Tool: "read", Arguments: `{"path":"my_file"}`},
+		CallRecord{Upstream: "fs", Tool: "read", Arguments: `{"path":"myXfile"}`},
+		CallRecord{Upstream: "fs", Tool: "read", Arguments: `{"path":"C:\\dir"}`},
+	)
+	tests := []struct {
+		search string
+		want   []int64
+	}{
+		{"a%", []int64{ids[0]}},
+		{"my_file", []int64{ids[2]}},
+		{`C:\\dir`, []int64{ids[4]}},
+		{"%", []int64{ids[0]}},
+		{"_", []int64{ids[2]}},
+	}
+	for _, tt := range tests {
+		if got, _ := queryIDs(t, CallFilter{Search: tt.search}); !slices.Equal(got, tt.want) {
+			t.Errorf("search %q: got %v, want %v", tt.search, got, tt.want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		v       string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"24h", now.Add(-24 * time.Hour), false},
+		{"2026-01-02T03:04:05Z", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), false},
+		{"-1h", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.v, now)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v, error %v", tt.v, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
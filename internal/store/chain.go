@@ -0,0 +1,355 @@
+package store
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Each audit record carries the hash of the record before it and a hash of its
+// own contents plus that previous hash, so editing, deleting or reordering a
+// record breaks every link after it. Pruning removes the oldest records only;
+// the first remaining record's prev_hash then anchors the chain, and the prune
+// record Prune appends vouches for that anchor, so records deleted from the
+// start of the log any other way are detected too.
+
+// chainVersion prefixes every hashed record, so a future change to what is
+// hashed cannot be confused with this one.
+const chainVersion = "gomcp-audit-chain-v1\n"
+
+// appendMu serializes RecordCall: each record needs the hash of the one
+// inserted before it. Other processes writing the same file are held off by
+// the immediate transaction lock.
+var appendMu sync.Mutex
+
+// chainedRecord is the canonical form of a record for hashing: every field of
+// CallRecord but the hash itself, in a fixed order.
+type chainedRecord struct {
+	ID                int64  `json:"id"`
+	Timestamp         string `json:"timestamp"`
+	Operation         string `json:"operation"`
+	Upstream          string `json:"upstream"`
+	Tool              string `json:"tool"`
+	Arguments         string `json:"arguments"`
+	Status            string `json:"status"`
+	Error             string `json:"error"`
+	DurationMs        int64  `json:"duration_ms"`
+	Rule              string `json:"rule"`
+	ApprovedArguments string `json:"approved_arguments"`
+	Caller            string `json:"caller"`
+	Result            string `json:"result"`
+	IsError           bool   `json:"is_error"`
+	Transport         string `json:"transport"`
+	SessionID         string `json:"session_id"`
+	Decision          string `json:"decision"`
+	Approver          string `json:"approver"`
+	RequestID         string `json:"request_id"`
+	PrevHash          string `json:"prev_hash"`
+}
+
+// chainHash returns the hex SHA-256 of r, as read back from the database,
+// linked to prevHash.
+func chainHash(r CallRecord, prevHash string) (string, error) {
+	b, err := json.Marshal(chainedRecord{
+		ID:                r.ID,
+		Timestamp:         r.Timestamp.UTC().Format(time.RFC3339Nano),
+		Operation:         r.Operation,
+		Upstream:          r.Upstream,
+		Tool:              r.Tool,
+		Arguments:         r.Arguments,
+		Status:            r.Status,
+		Error:             r.Error,
+		DurationMs:        r.DurationMs,
+		Rule:              r.Rule,
+		ApprovedArguments: r.ApprovedArguments,
+		Caller:            r.Caller,
+		Result:            r.Result,
+		IsError:           r.IsError,
+		Transport:         r.Transport,
+		SessionID:         r.SessionID,
+		Decision:          r.Decision,
+		Approver:          r.Approver,
+		RequestID:         r.RequestID,
+		PrevHash:          prevHash,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(chainVersion), b...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// sealRecord links the freshly inserted record id to the chain by filling in
+// its prev_hash and hash.
+func sealRecord(tx *sql.Tx, id int64) error {
+	var prev sql.NullString
+	err := tx.QueryRow(`SELECT hash FROM request_logs WHERE id < ? ORDER BY id DESC LIMIT 1`, id).Scan(&prev)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	rows, err := tx.Query(`SELECT `+callColumns+` FROM request_logs WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if !rows.Next() {
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("record %d not found", id)
+	}
+	r, err := scanCall(rows)
+	rows.Close()
+	if err != nil {
+		return err
+	}
+	hash, err := chainHash(r, prev.String)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE request_logs SET prev_hash = ?, hash = ? WHERE id = ?`, nullable(prev.String), hash, id)
+	return err
+}
+
+// BrokenLink is the first record at which the chain fails to verify.
+type BrokenLink struct {
+	ID     int64  `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// ChainStatus is the outcome of VerifyChain.
+type ChainStatus struct {
+	Records int64 `json:"records"` // records checked, up to the first broken link
+	FirstID int64 `json:"first_id,omitempty"`
+	LastID  int64 `json:"last_id,omitempty"`
+	// Anchor is the prev_hash of the first record: empty when the chain starts
+	// at the first record ever written, the hash of the newest pruned record
+	// otherwise. The newest prune record must name it.
+	Anchor   string      `json:"anchor,omitempty"`
+	LastHash string      `json:"last_hash,omitempty"`
+	Broken   *BrokenLink `json:"broken,omitempty"`
+}
+
+// VerifyChain recomputes the hash of every record, oldest first, and stops at
+// the first one that does not match its contents or does not link to the
+// record before it. A chain whose anchor the newest prune record does not
+// name is broken at its first record: records before it were deleted
+// without Prune.
+func VerifyChain() (ChainStatus, error) {
+	var st ChainStatus
+	if DB == nil {
+		return st, ErrNoStore
+	}
+	rows, err := DB.Query(`SELECT ` + callColumns + ` FROM request_logs ORDER BY id`)
+	if err != nil {
+		return st, err
+	}
+	defer rows.Close()
+
+	var pruned string // anchor named by the newest prune record
+	for rows.Next() {
+		r, err := scanCall(rows)
+		if err != nil {
+			return st, err
+		}
+		if st.Records == 0 {
+			st.FirstID = r.ID
+			st.Anchor = r.PrevHash
+		} else if r.PrevHash != st.LastHash {
+			st.Broken = &BrokenLink{ID: r.ID, Reason: "prev_hash does not match the hash of the record before it"}
+			return st, nil
+		}
+		want, err := chainHash(r, r.PrevHash)
+		if err != nil {
+			return st, err
+		}
+		switch {
+		case r.Hash == "":
+			st.Broken = &BrokenLink{ID: r.ID, Reason: "hash is missing"}
+			return st, nil
+		case r.Hash != want:
+			st.Broken = &BrokenLink{ID: r.ID, Reason: "hash does not match the record's contents"}
+			return st, nil
+		}
+		if r.Operation == OpPrune {
+			var res pruneResult
+			if err := json.Unmarshal([]byte(r.Result), &res); err != nil {
+				st.Broken = &BrokenLink{ID: r.ID, Reason: "prune record has no anchor"}
+				return st, nil
+			}
+			pruned = res.Anchor
+		}
+		st.Records++
+		st.LastID = r.ID
+		st.LastHash = r.Hash
+	}
+	if err := rows.Err(); err != nil {
+		return st, err
+	}
+	if st.Anchor != pruned {
+		return ChainStatus{
+			FirstID: st.FirstID,
+			Anchor:  st.Anchor,
+			Broken:  &BrokenLink{ID: st.FirstID, Reason: "records before it were deleted without a prune record"},
+		}, nil
+	}
+	return st, nil
+}
+
+// RecordHash returns the stored hash of record id, or sql.ErrNoRows if it does
+// not exist (or was pruned).
+func RecordHash(id int64) (string, error) {
+	if DB == nil {
+		return "", ErrNoStore
+	}
+	var hash sql.NullString
+	err := DB.QueryRow(`SELECT hash FROM request_logs WHERE id = ?`, id).Scan(&hash)
+	return hash.String, err
+}
+
+// Outcomes of CompareCheckpoint.
+const (
+	CheckpointMatch    = "match"    // the record still has the checkpoint's hash
+	CheckpointMismatch = "mismatch" // the record, or one before it, was rewritten
+	CheckpointMissing  = "missing"  // records from the checkpoint on were deleted
+	CheckpointPruned   = "pruned"   // the record was pruned; the checkpoint can no longer be checked
+)
+
+// CompareCheckpoint checks a checkpoint's record id and hash against st, the
+// intact chain VerifyChain returned.
+func CompareCheckpoint(st ChainStatus, id int64, hash string) (string, error) {
+	switch {
+	case st.Records == 0 || id > st.LastID:
+		return CheckpointMissing, nil
+	case id < st.FirstID:
+		if id == st.FirstID-1 && hash == st.Anchor {
+			return CheckpointMatch, nil
+		}
+		return CheckpointPruned, nil
+	}
+	got, err := RecordHash(id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// IDs are never reused, so a gap inside the chain is a deleted record
+		// whose neighbours were relinked.
+		return CheckpointMismatch, nil
+	case err != nil:
+		return "", err
+	case got != hash:
+		return CheckpointMismatch, nil
+	}
+	return CheckpointMatch, nil
+}
+
+// Checkpoint commits to the audit log as of its newest record. Keeping signed
+// checkpoints outside the gateway proves later that the records up to LastID
+// were not rewritten: the chain's hashes cover every record before LastHash.
+type Checkpoint struct {
+	LastID    int64     `json:"last_id"`
+	LastHash  string    `json:"last_hash"`
+	Records   int64     `json:"records"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SignedCheckpoint is a Checkpoint with an Ed25519 signature over its payload.
+type SignedCheckpoint struct {
+	Checkpoint Checkpoint `json:"checkpoint"`
+	PublicKey  []byte     `json:"public_key"` // informational; verify against a key you trust
+	Signature  []byte     `json:"signature"`
+}
+
+// payload is the byte string a checkpoint signature covers.
+func (c Checkpoint) payload() []byte {
+	return fmt.Appendf(nil, "gomcp-audit-checkpoint-v1\n%d\n%s\n%d\n%s\n",
+		c.LastID, c.LastHash, c.Records, c.CreatedAt.UTC().Format(time.RFC3339Nano))
+}
+
+// NewCheckpoint verifies the chain and signs a checkpoint of its newest record
+// with key. It fails if the chain is broken or empty.
+func NewCheckpoint(key ed25519.PrivateKey) (SignedCheckpoint, error) {
+	st, err := VerifyChain()
+	if err != nil {
+		return SignedCheckpoint{}, err
+	}
+	if st.Broken != nil {
+		return SignedCheckpoint{}, fmt.Errorf("chain broken at record %d: %s", st.Broken.ID, st.Broken.Reason)
+	}
+	if st.Records == 0 {
+		return SignedCheckpoint{}, errors.New("audit log is empty")
+	}
+	c := Checkpoint{LastID: st.LastID, LastHash: st.LastHash, Records: st.Records, CreatedAt: time.Now().UTC()}
+	return SignedCheckpoint{
+		Checkpoint: c,
+		PublicKey:  key.Public().(ed25519.PublicKey),
+		Signature:  ed25519.Sign(key, c.payload()),
+	}, nil
+}
+
+// Verify reports whether the checkpoint was signed by pub.
+func (sc SignedCheckpoint) Verify(pub ed25519.PublicKey) bool {
+	return ed25519.Verify(pub, sc.Checkpoint.payload(), sc.Signature)
+}
+
+// LoadSigningKey reads a PEM-encoded PKCS #8 Ed25519 private key, as written by
+// `openssl genpkey -algorithm ed25519`.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	ed, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 private key", path)
+	}
+	return ed, nil
+}
+
+// LoadPublicKey reads a PEM-encoded Ed25519 public key, as written by
+// `openssl pkey -pubout`. A private key file is accepted too.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type == "PRIVATE KEY" {
+		key, err := LoadSigningKey(path)
+		if err != nil {
+			return nil, err
+		}
+		return key.Public().(ed25519.PublicKey), nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	ed, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 public key", path)
+	}
+	return ed, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	return block, nil
+}
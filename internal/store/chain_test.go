@@ -0,0 +1,246 @@
+package store
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordN appends n tool calls to the audit log and returns their IDs.
+func recordN(t *testing.T, n int) []int64 {
+	t.Helper()
+	var ids []int64
+	for i := range n {
+		if err := RecordCall(CallRecord{Operation: "tools/call", Upstream: "fs", Tool: fmt.Sprintf("tool%d", i), Arguments: `{}`, Status: StatusSuccess}); err != nil {
+			t.Fatal(err)
+		}
+		var id int64
+		if err := DB.QueryRow(`SELECT MAX(id) FROM request_logs`).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func mustVerify(t *testing.T) ChainStatus {
+	t.Helper()
+	st, err := VerifyChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func mustHash(t *testing.T, id int64) string {
+	t.Helper()
+	h, err := RecordHash(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestVerifyChain(t *testing.T) {
+	openMemory(t)
+	if st := mustVerify(t); st.Records != 0 || st.Broken != nil {
+		t.Fatalf("empty log: %+v", st)
+	}
+
+	ids := recordN(t, 3)
+	st := mustVerify(t)
+	if st.Broken != nil || st.Records != 3 || st.FirstID != ids[0] || st.LastID != ids[2] || st.Anchor != "" {
+		t.Fatalf("status = %+v", st)
+	}
+	if h := mustHash(t, ids[2]); st.LastHash != h {
+		t.Errorf("LastHash = %s, want %s", st.LastHash, h)
+	}
+	var prev string
+	if err := DB.QueryRow(`SELECT prev_hash FROM request_logs WHERE id = ?`, ids[1]).Scan(&prev); err != nil {
+		t.Fatal(err)
+	}
+	if prev != mustHash(t, ids[0]) {
+		t.Errorf("record %d does not link to record %d", ids[1], ids[0])
+	}
+}
+
+func TestVerifyChainDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper string // SQL run with the IDs of five records as ?1 to ?5
+		at     int    // index of the record the chain must break at
+		reason string
+	}{
+		{"edited arguments", `UPDATE request_logs SET arguments = '{"path":"/etc/passwd"}' WHERE id = ?3`, 2, "contents"},
+		{"edited status", `UPDATE request_logs SET status = 'error' WHERE id = ?5`, 4, "contents"},
+		{"deleted record", `DELETE FROM request_logs WHERE id = ?3`, 3, "prev_hash"},
+		{"cleared hash", `UPDATE request_logs SET hash = NULL WHERE id = ?4`, 3, "hash is missing"},
+		{"deleted head", `DELETE FROM request_logs WHERE id <= ?2`, 2, "without a prune record"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openMemory(t)
+			ids := recordN(t, 5)
+			args := make([]any, len(ids))
+			for i, id := range ids {
+				args[i] = id
+			}
+			if _, err := DB.Exec(tt.tamper, args...); err != nil {
+				t.Fatal(err)
+			}
+			st := mustVerify(t)
+			if st.Broken == nil {
+				t.Fatalf("tampering not detected: %+v", st)
+			}
+			if st.Broken.ID != ids[tt.at] || !strings.Contains(st.Broken.Reason, tt.reason) {
+				t.Errorf("broken at %d (%s), want %d (%s)", st.Broken.ID, st.Broken.Reason, ids[tt.at], tt.reason)
+			}
+		})
+	}
+}
+
+func TestPruneAnchorsChain(t *testing.T) {
+	openMemory(t)
+	ids := recordN(t, 5)
+	anchor := mustHash(t, ids[2])
+
+	n, err := Prune(time.Time{}, 3, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("deleted %d records, want 3", n)
+	}
+	st := mustVerify(t)
+	if st.Broken != nil || st.FirstID != ids[3] || st.Records != 3 || st.Anchor != anchor {
+		t.Fatalf("after prune: %+v, want anchor %s", st, anchor)
+	}
+
+	// The prune itself is the newest record.
+	calls, _, err := QueryCalls(CallFilter{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := calls[0]
+	var res pruneResult
+	if err := json.Unmarshal([]byte(p.Result), &res); err != nil {
+		t.Fatal(err)
+	}
+	if p.Operation != OpPrune || p.Caller != "admin" || p.Arguments != `{"max_rows":3}` || res != (pruneResult{Deleted: 3, ThroughID: ids[2], Anchor: anchor}) {
+		t.Errorf("prune record = %+v, result %+v", p, res)
+	}
+
+	// Nothing left to prune: no record is added.
+	if n, err := Prune(time.Time{}, 10, ""); err != nil || n != 0 {
+		t.Fatalf("second prune = %d, %v", n, err)
+	}
+	if st := mustVerify(t); st.LastID != p.ID {
+		t.Errorf("empty prune was recorded: last ID %d, want %d", st.LastID, p.ID)
+	}
+
+	// Pruning everything, the earlier prune record included, leaves the new
+	// prune record to vouch for the anchor.
+	before := mustHash(t, p.ID)
+	if _, err := Prune(time.Now().Add(time.Second), 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	st = mustVerify(t)
+	if st.Broken != nil || st.Records != 1 || st.Anchor != before {
+		t.Fatalf("after pruning everything: %+v", st)
+	}
+
+	// Deleting that record by hand leaves nothing to check; deleting from the
+	// start of a longer log does not go unnoticed.
+	recordN(t, 2)
+	if _, err := DB.Exec(`DELETE FROM request_logs WHERE id = ?`, st.FirstID); err != nil {
+		t.Fatal(err)
+	}
+	st = mustVerify(t)
+	if st.Broken == nil || !strings.Contains(st.Broken.Reason, "without a prune record") {
+		t.Errorf("deleting the prune record went unnoticed: %+v", st)
+	}
+}
+
+func TestPruneWithoutStore(t *testing.T) {
+	if _, err := Prune(time.Now(), 0, ""); err != ErrNoStore {
+		t.Errorf("err = %v, want ErrNoStore", err)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	openMemory(t)
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCheckpoint(key); err == nil {
+		t.Error("checkpoint of an empty log")
+	}
+
+	ids := recordN(t, 3)
+	cp, err := NewCheckpoint(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cp.Verify(pub) || cp.Checkpoint.LastID != ids[2] || cp.Checkpoint.Records != 3 {
+		t.Fatalf("checkpoint = %+v", cp.Checkpoint)
+	}
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	if cp.Verify(other) {
+		t.Error("checkpoint verifies with another key")
+	}
+	forged := cp
+	forged.Checkpoint.LastHash = strings.Repeat("0", 64)
+	if forged.Verify(pub) {
+		t.Error("edited checkpoint verifies")
+	}
+
+	compare := func(id int64, hash string) string {
+		t.Helper()
+		got, err := CompareCheckpoint(mustVerify(t), id, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	c := cp.Checkpoint
+	recordN(t, 2)
+	if got := compare(c.LastID, c.LastHash); got != CheckpointMatch {
+		t.Errorf("intact log: %s", got)
+	}
+	if got := compare(c.LastID, forged.Checkpoint.LastHash); got != CheckpointMismatch {
+		t.Errorf("other hash: %s", got)
+	}
+
+	// Pruning up to the checkpoint keeps it checkable through the anchor;
+	// pruning past it does not.
+	if _, err := Prune(time.Time{}, 3, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := compare(c.LastID, c.LastHash); got != CheckpointMatch {
+		t.Errorf("pruned up to the checkpoint: %s", got)
+	}
+	if _, err := Prune(time.Time{}, 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := compare(c.LastID, c.LastHash); got != CheckpointPruned {
+		t.Errorf("pruned past the checkpoint: %s", got)
+	}
+
+	// Cutting the log short before a newer checkpoint.
+	recordN(t, 2)
+	cp, err = NewCheckpoint(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DB.Exec(`DELETE FROM request_logs WHERE id >= ?`, cp.Checkpoint.LastID); err != nil {
+		t.Fatal(err)
+	}
+	if got := compare(cp.Checkpoint.LastID, cp.Checkpoint.LastHash); got != CheckpointMissing {
+		t.Errorf("truncated log: %s", got)
+	}
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrNoStore is returned by operations that need the database when InitStore
+// has not been called (e.g. in stdio mode).
+var ErrNoStore = errors.New("store not initialized")
+
+// Grant is a standing approval: calls to Upstream/Tool skip the approval
+// prompt until ExpiresAt. A non-empty Arguments limits it to calls with
+// exactly those (JSON-encoded) arguments.
+type Grant struct {
+	ID        int64      `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Upstream  string     `json:"upstream"`
+	Tool      string     `json:"tool"`
+	Arguments string     `json:"arguments,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// AddGrant persists g and returns its ID. Times are stored in UTC so that
+// SQLite's text comparison orders them correctly.
+func AddGrant(g Grant) (int64, error) {
+	if DB == nil {
+		return 0, ErrNoStore
+	}
+	var args, expires any
+	if g.Arguments != "" {
+		args = g.Arguments
+	}
+	if g.ExpiresAt != nil {
+		expires = g.ExpiresAt.UTC()
+	}
+	res, err := DB.Exec(`
+		INSERT INTO grants (created_at, upstream, tool, arguments, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, time.Now().UTC(), g.Upstream, g.Tool, args, expires)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// MatchGrant returns the ID of an unexpired grant covering the call, or 0.
+func MatchGrant(upstream, tool, args string) (int64, error) {
+	if DB == nil {
+		return 0, ErrNoStore
+	}
+	var id int64
+	err := DB.QueryRow(`
+		SELECT id FROM grants
+		WHERE upstream = ? AND tool = ?
+		  AND (arguments IS NULL OR arguments = ?)
+		  AND (expires_at IS NULL OR expires_at > ?)
+		ORDER BY id LIMIT 1
+	`, upstream, tool, args, time.Now().UTC()).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// ListGrants returns the unexpired grants, newest first. Expired ones are deleted.
+func ListGrants() ([]Grant, error) {
+	if DB == nil {
+		return []Grant{}, nil
+	}
+	if _, err := DB.Exec(`DELETE FROM grants WHERE expires_at IS NOT NULL AND expires_at <= ?`, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	rows, err := DB.Query(`
+		SELECT id, created_at, upstream, tool, arguments, expires_at
+		FROM grants
+		ORDER BY id DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []Grant{}
+	for rows.Next() {
+		var g Grant
+		var args sql.NullString
+		var expires sql.NullTime
+		if err := rows.Scan(&g.ID, &g.CreatedAt, &g.Upstream, &g.Tool, &args, &expires); err != nil {
+			return nil, err
+		}
+		g.Arguments = args.String
+		if expires.Valid {
+			t := expires.Time
+			g.ExpiresAt = &t
+		}
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
+
+// RevokeGrant deletes a grant. It reports false if no such grant exists.
+func RevokeGrant(id int64) (bool, error) {
+	if DB == nil {
+		return false, ErrNoStore
+	}
+	res, err := DB.Exec(`DELETE FROM grants WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMatchGrant(t *testing.T) {
+	openMemory(t)
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	add := func(g Grant) int64 {
+		t.Helper()
+		id, err := AddGrant(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	expired := add(Grant{Upstream: "fs", Tool: "write", ExpiresAt: &past})
+	exact := add(Grant{Upstream: "fs", Tool: "write", Arguments: `{"path":"a"}`})
+	hour := add(Grant{Upstream: "fs", Tool: "delete", ExpiresAt: &future})
+
+	tests := []struct {
+		upstream, tool, args string
+		want                 int64
+	}{
+		{"fs", "write", `{"path":"a"}`, exact},
+		{"fs", "write", `{"path":"b"}`, 0},
+		{"fs", "delete", `{"path":"b"}`, hour},
+		{"git", "delete", `{}`, 0},
+	}
+	for _, tt := range tests {
+		got, err := MatchGrant(tt.upstream, tt.tool, tt.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("MatchGrant(%s, %s, %s) = %d, want %d", tt.upstream, tt.tool, tt.args, got, tt.want)
+		}
+	}
+
+	grants, err := ListGrants()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grants) != 2 || grants[0].ID != hour || grants[1].ID != exact {
+		t.Errorf("ListGrants = %+v, want the hour and exact grants, newest first", grants)
+	}
+	if ok, err := RevokeGrant(expired); err != nil || ok {
+		t.Errorf("revoking a grant ListGrants already dropped: ok = %v, err = %v", ok, err)
+	}
+	if ok, err := RevokeGrant(exact); err != nil || !ok {
+		t.Fatalf("RevokeGrant: ok = %v, err = %v", ok, err)
+	}
+	if got, _ := MatchGrant("fs", "write", `{"path":"a"}`); got != 0 {
+		t.Errorf("revoked grant %d still matches", got)
+	}
+}
+
+func TestGrantsWithoutStore(t *testing.T) {
+	if _, err := MatchGrant("fs", "write", `{}`); !errors.Is(err, ErrNoStore) {
+		t.Errorf("MatchGrant err = %v, want ErrNoStore", err)
+	}
+	if _, err := AddGrant(Grant{Upstream: "fs", Tool: "write"}); !errors.Is(err, ErrNoStore) {
+		t.Errorf("AddGrant err = %v, want ErrNoStore", err)
+	}
+}
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// migrations upgrade the schema one step each, in order. The database's
+// PRAGMA user_version counts how many have run. Only ever append: a released
+// migration must not change, or databases that already ran it diverge.
+var migrations = []func(tx *sql.Tx) error{
+	migrateBaseline,
+	migrateCallDetails,
+	migrateOperation,
+	migrateHashChain,
+	migratePruneAnchor,
+}
+
+// migrate runs the migrations db has not seen yet, each in its own transaction.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this build supports (%d)", version, len(migrations))
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := migrations[i](tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		// PRAGMA does not take bind parameters.
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// migrateBaseline creates the tables as they were before versioning. Databases
+// from those releases may lack columns added over time, so they are added
+// where missing.
+func migrateBaseline(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS request_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			upstream TEXT NOT NULL,
+			tool TEXT NOT NULL,
+			arguments TEXT,
+			status TEXT,
+			error TEXT,
+			duration_ms INTEGER
+		);
+		CREATE INDEX IF NOT EXISTS idx_timestamp ON request_logs(timestamp DESC);
+		CREATE TABLE IF NOT EXISTS grants (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_at DATETIME NOT NULL,
+			upstream TEXT NOT NULL,
+			tool TEXT NOT NULL,
+			arguments TEXT,
+			expires_at DATETIME
+		);
+		CREATE INDEX IF NOT EXISTS idx_grants_tool ON grants(upstream, tool);
+	`); err != nil {
+		return err
+	}
+	return addMissingColumns(tx, "request_logs", []column{
+		{"rule", "TEXT"},
+		{"approved_arguments", "TEXT"},
+		{"caller", "TEXT"},
+	})
+}
+
+// migrateCallDetails records what a tool returned and where the call came
+// from: the transport, the MCP session, the approval decision and who made
+// it, and the request ID the call's log lines carry.
+func migrateCallDetails(tx *sql.Tx) error {
+	if err := addMissingColumns(tx, "request_logs", []column{
+		{"result", "TEXT"},
+		{"is_error", "INTEGER"},
+		{"transport", "TEXT"},
+		{"session_id", "TEXT"},
+		{"decision", "TEXT"},
+		{"approver", "TEXT"},
+		{"request_id", "TEXT"},
+	}); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_request_logs_request_id ON request_logs(request_id)`)
+	return err
+}
+
+// migrateOperation records which MCP operation a row audits. Everything
+// recorded before was a tool call.
+func migrateOperation(tx *sql.Tx) error {
+	if err := addMissingColumns(tx, "request_logs", []column{
+		{"operation", "TEXT NOT NULL DEFAULT 'tools/call'"},
+	}); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_request_logs_operation ON request_logs(operation)`)
+	return err
+}
+
+// migrateHashChain adds the tamper-evident hash chain and links the records
+// already written, oldest first, so the chain covers them too.
+func migrateHashChain(tx *sql.Tx) error {
+	if err := addMissingColumns(tx, "request_logs", []column{
+		{"prev_hash", "TEXT"},
+		{"hash", "TEXT"},
+	}); err != nil {
+		return err
+	}
+	rows, err := tx.Query(`SELECT id FROM request_logs ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := sealRecord(tx, id); err != nil {
+			return fmt.Errorf("hash record %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// migratePruneAnchor vouches for the anchor of a chain pruned before prunes
+// were recorded, so VerifyChain accepts it.
+func migratePruneAnchor(tx *sql.Tx) error {
+	var (
+		first int64
+		prev  sql.NullString
+	)
+	err := tx.QueryRow(`SELECT id, prev_hash FROM request_logs ORDER BY id LIMIT 1`).Scan(&first, &prev)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !prev.Valid) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var recorded bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM request_logs WHERE operation = ?)`, OpPrune).Scan(&recorded); err != nil || recorded {
+		return err
+	}
+	result, err := json.Marshal(pruneResult{ThroughID: first - 1, Anchor: prev.String})
+	if err != nil {
+		return err
+	}
+	return appendRecord(tx, CallRecord{Operation: OpPrune, Arguments: `{}`, Status: StatusSuccess, Result: string(result)})
+}
+
+type column struct{ name, def string }
+
+func addMissingColumns(tx *sql.Tx, table string, cols []column) error {
+	rows, err := tx.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, col := range cols {
+		if existing[col.name] {
+			continue
+		}
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, col.name, col.def)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// legacyDB writes a database as releases before schema versioning left it:
+// the first request_logs columns only, holding two tool calls.
+func legacyDB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+		CREATE TABLE request_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			upstream TEXT NOT NULL,
+			tool TEXT NOT NULL,
+			arguments TEXT,
+			status TEXT,
+			error TEXT,
+			duration_ms INTEGER
+		);
+		INSERT INTO request_logs (upstream, tool, arguments, status, duration_ms) VALUES
+			('fs', 'read', '{"path":"a"}', 'success', 3),
+			('fs', 'write', '{"path":"b"}', 'error', 5);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// closeStore closes the store a test opened.
+func closeStore(t *testing.T) {
+	t.Cleanup(func() {
+		Close()
+		DB = nil
+	})
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	path := legacyDB(t)
+	if err := InitStore(path); err != nil {
+		t.Fatal(err)
+	}
+	closeStore(t)
+
+	var version int
+	if err := DB.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version != len(migrations) {
+		t.Errorf("user_version = %d, want %d", version, len(migrations))
+	}
+	calls, _, err := QueryCalls(CallFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[1].Operation != "tools/call" || calls[1].Tool != "read" || calls[0].Hash == "" {
+		t.Fatalf("migrated records = %+v", calls)
+	}
+
+	// Existing records were linked into the chain, oldest first, and new
+	// ones continue it.
+	recordN(t, 1)
+	st := mustVerify(t)
+	if st.Broken != nil || st.Records != 3 || st.Anchor != "" {
+		t.Errorf("chain after migration: %+v", st)
+	}
+
+	// Opening again runs nothing and changes nothing.
+	Close()
+	if err := InitStore(path); err != nil {
+		t.Fatal(err)
+	}
+	if again := mustVerify(t); again != st {
+		t.Errorf("chain after reopening: %+v, want %+v", again, st)
+	}
+}
+
+func TestMigratePrunedChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.db")
+	if err := InitStore(path); err != nil {
+		t.Fatal(err)
+	}
+	closeStore(t)
+	ids := recordN(t, 3)
+	anchor := mustHash(t, ids[0])
+
+	// A prune from before prunes were recorded, at the previous schema version.
+	if _, err := DB.Exec(`DELETE FROM request_logs WHERE id = ?`, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DB.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, len(migrations)-1)); err != nil {
+		t.Fatal(err)
+	}
+	if st := mustVerify(t); st.Broken == nil {
+		t.Fatalf("unrecorded prune went unnoticed: %+v", st)
+	}
+	Close()
+
+	if err := InitStore(path); err != nil {
+		t.Fatal(err)
+	}
+	st := mustVerify(t)
+	if st.Broken != nil || st.Records != 3 || st.Anchor != anchor {
+		t.Errorf("chain after migration: %+v", st)
+	}
+}
+
+func TestMigrateRejectsNewerSchema(t *testing.T) {
+	path := legacyDB(t)
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`PRAGMA user_version = 99`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	closeStore(t)
+	if err := InitStore(path); err == nil || !strings.Contains(err.Error(), "newer than this build") {
+		t.Errorf("err = %v, want a newer schema error", err)
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.db")
+	if err := InitStore(path); err != nil {
+		t.Fatal(err)
+	}
+	recordN(t, 2)
+	Close()
+
+	closeStore(t)
+	if err := OpenReadOnly(path); err != nil {
+		t.Fatal(err)
+	}
+	if st := mustVerify(t); st.Broken != nil || st.Records != 2 {
+		t.Errorf("chain = %+v", st)
+	}
+	if err := RecordCall(CallRecord{Operation: "tools/call", Upstream: "fs", Tool: "read"}); err == nil {
+		t.Error("wrote to a database opened read-only")
+	}
+	Close()
+
+	if err := OpenReadOnly(legacyDB(t)); err == nil || !strings.Contains(err.Error(), "schema version 0") {
+		t.Errorf("legacy database: err = %v, want a schema version error", err)
+	}
+	if err := OpenReadOnly(filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Error("opened a missing database")
+	}
+}
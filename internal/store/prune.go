@@ -0,0 +1,121 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// OpPrune is the operation of the record Prune appends to the audit log.
+const OpPrune = "audit/prune"
+
+// pruneResult is the Result of an OpPrune record. Anchor is the hash of the
+// newest record deleted, which the first record kept links to.
+type pruneResult struct {
+	Deleted   int64  `json:"deleted"`
+	ThroughID int64  `json:"through_id"`
+	Anchor    string `json:"anchor"`
+}
+
+// Prune deletes the audit records older than before, unless before is zero,
+// and then the oldest records beyond maxRows, unless maxRows is zero; the
+// record of the prune itself is among the maxRows left. It returns how many
+// records were deleted. Grants are not affected.
+//
+// Only a prefix of the log is ever deleted, up to the newest record older than
+// before, so the hash chain of the records kept stays intact. A prune that
+// deletes anything appends an OpPrune record naming the hash the chain is now
+// anchored at; VerifyChain rejects an anchor no such record accounts for.
+// caller names the API token that asked for the prune, if any.
+func Prune(before time.Time, maxRows int, caller string) (int64, error) {
+	if DB == nil {
+		return 0, ErrNoStore
+	}
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var through int64
+	if !before.IsZero() {
+		var id sql.NullInt64
+		if err := tx.QueryRow(`SELECT MAX(id) FROM request_logs WHERE timestamp < ?`, before.UTC()).Scan(&id); err != nil {
+			return 0, err
+		}
+		through = id.Int64
+	}
+	if maxRows > 0 {
+		var kept int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM request_logs WHERE id > ?`, through).Scan(&kept); err != nil {
+			return 0, err
+		}
+		// The prune record counts against maxRows too, so a prune keeps only
+		// the newest maxRows-1 records.
+		if kept > maxRows || (through > 0 && kept >= maxRows) {
+			if err := tx.QueryRow(`SELECT id FROM request_logs ORDER BY id DESC LIMIT 1 OFFSET ?`, maxRows-1).Scan(&through); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if through == 0 {
+		return 0, nil
+	}
+
+	var (
+		anchor  sql.NullString
+		deleted int64
+	)
+	if err := tx.QueryRow(`SELECT hash FROM request_logs WHERE id = ?`, through).Scan(&anchor); err != nil {
+		return 0, err
+	}
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM request_logs WHERE id <= ?`, through).Scan(&deleted); err != nil {
+		return 0, err
+	}
+
+	args := map[string]any{}
+	if !before.IsZero() {
+		args["before"] = before.UTC().Format(time.RFC3339)
+	}
+	if maxRows > 0 {
+		args["max_rows"] = maxRows
+	}
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return 0, err
+	}
+	result, err := json.Marshal(pruneResult{Deleted: deleted, ThroughID: through, Anchor: anchor.String})
+	if err != nil {
+		return 0, err
+	}
+	err = appendRecord(tx, CallRecord{
+		Operation: OpPrune,
+		Arguments: string(argsJSON),
+		Status:    StatusSuccess,
+		Caller:    caller,
+		Result:    string(result),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("record prune: %w", err)
+	}
+	// The prune record is linked to the chain first, so it has a record
+	// before it even when every other record goes.
+	if _, err := tx.Exec(`DELETE FROM request_logs WHERE id <= ?`, through); err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
+
+// Vacuum compacts the database file, returning the space of deleted records
+// to the file system.
+func Vacuum() error {
+	if DB == nil {
+		return ErrNoStore
+	}
+	_, err := DB.Exec(`VACUUM`)
+	return err
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+// cutoff returns a time after every record so far and before any recorded later.
+func cutoff(t *testing.T) time.Time {
+	t.Helper()
+	time.Sleep(2 * time.Millisecond)
+	at := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	return at
+}
+
+func TestPruneRetention(t *testing.T) {
+	openMemory(t)
+	prune := func(before time.Time, maxRows int, wantDeleted, wantRecords int64) ChainStatus {
+		t.Helper()
+		n, err := Prune(before, maxRows, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		st := mustVerify(t)
+		if n != wantDeleted || st.Broken != nil || st.Records != wantRecords {
+			t.Fatalf("Prune(%v, %d) deleted %d, chain %+v; want %d deleted, %d records", before, maxRows, n, st, wantDeleted, wantRecords)
+		}
+		return st
+	}
+
+	// Records older than the cutoff go.
+	recordN(t, 3)
+	before := cutoff(t)
+	recent := recordN(t, 4)
+	st := prune(before, 0, 3, 5)
+	if st.FirstID != recent[0] {
+		t.Errorf("first record %d, want %d", st.FirstID, recent[0])
+	}
+	prune(before, 0, 0, 5)
+
+	// max_rows leaves that many records, the prune record included.
+	st = prune(time.Time{}, 3, 3, 3)
+	if st.FirstID != recent[3] {
+		t.Errorf("first record %d, want %d", st.FirstID, recent[3])
+	}
+	prune(time.Time{}, 3, 0, 3)
+
+	// Both: max_rows applies to the records newer than the cutoff.
+	before = cutoff(t)
+	recent = recordN(t, 4)
+	st = prune(before, 3, 5, 3)
+	if st.FirstID != recent[2] {
+		t.Errorf("first record %d, want %d", st.FirstID, recent[2])
+	}
+
+	// A cutoff that keeps fewer records than max_rows wins.
+	before = cutoff(t)
+	recordN(t, 1)
+	prune(before, 3, 3, 2)
+
+	// max_rows: 1 keeps only the prune record.
+	recordN(t, 2)
+	prune(time.Time{}, 1, 4, 1)
+}
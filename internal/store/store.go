@@ -12,93 +12,162 @@ import (
 
 var DB *sql.DB
 
-const schema = `
-CREATE TABLE IF NOT EXISTS request_logs (
-	id INTEGER PRIMARY KEY AUTOINCREMENT,
-	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-	upstream TEXT NOT NULL,
-	tool TEXT NOT NULL,
-	arguments TEXT,
-	status TEXT,
-	error TEXT,
-	duration_ms INTEGER
-);
-CREATE INDEX IF NOT EXISTS idx_timestamp ON request_logs(timestamp DESC);
-`
-
-// CallRecord represents a single tool invocation log.
+// Call statuses recorded in request_logs.
+const (
+	StatusSuccess   = "success"
+	StatusError     = "error"
+	StatusCancelled = "cancelled"
+	StatusTimeout   = "timeout"
+)
+
+// CallRecord represents a single audited operation: a tool call, resource
+// read, prompt get or listing.
 type CallRecord struct {
-	ID         int64
-	Timestamp  time.Time
-	Upstream   string
-	Tool       string
-	Arguments  string
-	Status     string
-	Error      string
-	DurationMs int64
+	ID        int64     `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	// Operation is the MCP method, such as "tools/call" or "resources/read".
+	Operation string `json:"operation"`
+	Upstream  string `json:"upstream"`
+	// Tool names the target of the operation: the tool, the prompt or the
+	// resource URI. It is empty for listings.
+	Tool       string `json:"tool"`
+	Arguments  string `json:"arguments,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Rule       string `json:"rule,omitempty"` // policy rule ("grant:<id>" for a standing grant) that decided the call, if any
+	// ApprovedArguments are the arguments the call ran with when a reviewer
+	// edited them; Arguments keeps what the client sent.
+	ApprovedArguments string `json:"approved_arguments,omitempty"`
+	Caller            string `json:"caller,omitempty"` // name of the API token that made the call, if any
+	// Result is the operation's result as JSON, with binary content replaced by
+	// its size and SHA-256 and text cut past a size limit. Listings record
+	// only the number of entries returned.
+	Result    string `json:"result,omitempty"`
+	IsError   bool   `json:"is_error"`             // the tool reported an error in its result
+	Transport string `json:"transport,omitempty"`  // rest, sse, streamable-http or stdio
+	SessionID string `json:"session_id,omitempty"` // MCP session, for sse, streamable-http and stdio
+	Decision  string `json:"decision,omitempty"`   // approved or denied
+	Approver  string `json:"approver,omitempty"`   // who or what made the decision
+	RequestID string `json:"request_id,omitempty"` // also logged with every log line about the call
+	// PrevHash and Hash chain the records together; see VerifyChain.
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
 }
 
-// InitStore initializes the SQLite database.
-func InitStore() error {
-	home, _ := os.UserHomeDir()
-	dbPath := filepath.Join(home, ".gomcp", "audit.db")
+// Memory is the path of a database kept in memory instead of on disk.
+const Memory = ":memory:"
 
-	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
-		return fmt.Errorf("create db dir: %w", err)
+// InitStore opens the SQLite database at dbPath, creating it if needed, and
+// brings its schema up to date.
+func InitStore(dbPath string) error {
+	dsn := Memory
+	if dbPath != Memory {
+		if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+			return fmt.Errorf("create db dir: %w", err)
+		}
+		// Writers take the lock up front, so two processes appending to the
+		// hash chain cannot both read the same last record.
+		dsn = dbPath + "?_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
 	}
 
 	var err error
-	DB, err = sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_busy_timeout=5000")
+	DB, err = sql.Open("sqlite3", dsn)
 	if err != nil {
 		return fmt.Errorf("open db: %w", err)
 	}
+	if dbPath == Memory {
+		// Every connection to ":memory:" opens a database of its own.
+		DB.SetMaxOpenConns(1)
+	}
 
-	if _, err := DB.Exec(schema); err != nil {
-		return fmt.Errorf("init schema: %w", err)
+	if err := migrate(DB); err != nil {
+		return fmt.Errorf("migrate schema: %w", err)
 	}
 
 	return nil
 }
 
-// RecordCall logs a tool execution.
-func RecordCall(upstream, tool, args string, status string, errStr string, duration time.Duration) error {
+// OpenReadOnly opens an existing database without changing it, to check the
+// audit log offline. Its schema must be the one this build writes: migrating
+// would rewrite the file under inspection.
+func OpenReadOnly(dbPath string) error {
+	if _, err := os.Stat(dbPath); err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro&_busy_timeout=5000")
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		db.Close()
+		return fmt.Errorf("open db: %w", err)
+	}
+	if version != len(migrations) {
+		db.Close()
+		return fmt.Errorf("%s has schema version %d, this build reads %d; open it with a matching gomcp", dbPath, version, len(migrations))
+	}
+	DB = db
+	return nil
+}
+
+// RecordCall logs an operation and links it to the hash chain. ID, Timestamp,
+// PrevHash and Hash are assigned by the store.
+func RecordCall(r CallRecord) error {
 	if DB == nil {
 		return nil
 	}
-	_, err := DB.Exec(`
-		INSERT INTO request_logs (timestamp, upstream, tool, arguments, status, error, duration_ms)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, time.Now(), upstream, tool, args, status, errStr, duration.Milliseconds())
-	return err
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := appendRecord(tx, r); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// GetRecentCalls retrieves the last N calls.
-func GetRecentCalls(limit int) ([]CallRecord, error) {
-	if DB == nil {
-		return nil, nil
+// appendRecord inserts r and links it to the hash chain. appendMu must be held.
+func appendRecord(tx *sql.Tx, r CallRecord) error {
+	res, err := tx.Exec(`
+		INSERT INTO request_logs (timestamp, operation, upstream, tool, arguments, status, error, duration_ms, rule, approved_arguments, caller,
+			result, is_error, transport, session_id, decision, approver, request_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, time.Now().UTC(), r.Operation, r.Upstream, r.Tool, r.Arguments, r.Status, r.Error, r.DurationMs, r.Rule, nullable(r.ApprovedArguments), nullable(r.Caller),
+		nullable(r.Result), r.IsError, nullable(r.Transport), nullable(r.SessionID), nullable(r.Decision), nullable(r.Approver), nullable(r.RequestID))
+	if err != nil {
+		return err
 	}
-	rows, err := DB.Query(`
-		SELECT id, timestamp, upstream, tool, arguments, status, error, duration_ms
-		FROM request_logs
-		ORDER BY timestamp DESC
-		LIMIT ?
-	`, limit)
+	id, err := res.LastInsertId()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	if err := sealRecord(tx, id); err != nil {
+		return fmt.Errorf("hash record: %w", err)
+	}
+	return nil
+}
 
-	var records []CallRecord
-	for rows.Next() {
-		var r CallRecord
-		var errStr sql.NullString
-		if err := rows.Scan(&r.ID, &r.Timestamp, &r.Upstream, &r.Tool, &r.Arguments, &r.Status, &errStr, &r.DurationMs); err != nil {
-			return nil, err
-		}
-		r.Error = errStr.String
-		records = append(records, r)
+// nullable stores empty strings as NULL.
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// GetRecentCalls retrieves the last N calls.
+func GetRecentCalls(limit int) ([]CallRecord, error) {
+	if DB == nil {
+		return nil, nil
 	}
-	return records, nil
+	records, _, err := QueryCalls(CallFilter{Limit: limit})
+	return records, err
 }
 
 func Close() {
@@ -0,0 +1,15 @@
+package store
+
+import "testing"
+
+// openMemory gives the test a fresh in-memory store and closes it afterwards.
+func openMemory(t *testing.T) {
+	t.Helper()
+	if err := InitStore(Memory); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		Close()
+		DB = nil
+	})
+}
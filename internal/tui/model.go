@@ -1,35 +1,33 @@
 package tui
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"go.uber.org/zap"
 
+	"gomcp-pilot/internal/approval"
 	"gomcp-pilot/internal/config"
 	"gomcp-pilot/internal/logger"
+	"gomcp-pilot/internal/schema"
+	"gomcp-pilot/internal/store"
 )
 
-// InterceptRequest represents a request that needs user approval.
-type InterceptRequest struct {
-	Upstream string
-	Tool     string
-	Args     string
-	// ResponseChan is used to send the user's decision back to the bridge.
-	// true = approve, false = deny
-	ResponseChan chan bool
-}
-
-// InterceptChan is the global channel for incoming interception requests.
-var InterceptChan = make(chan InterceptRequest)
-
 // UpstreamStatus tracks the state of each upstream service
 type UpstreamStatus struct {
 	Name      string
-	Status    string // "Running", "Stopped", "Error"
+	Status    string // "Starting", "Running", "Restarting", "Failed"
+	Restarts  int
+	LastError string
 	CallCount int
 	LastCall  time.Time
 	Config    config.Upstream
@@ -41,12 +39,32 @@ type ToolInfo struct {
 	Description string
 }
 
+// HealthInfo is the supervisor state of an upstream as reported by the process manager.
+type HealthInfo struct {
+	Status    string
+	Restarts  int
+	LastError string
+	Config    config.Upstream
+}
+
 type Model struct {
-	logs           []string
-	requestPending *InterceptRequest
-	quitting       bool
-	width          int
-	height         int
+	logs     []string
+	quitting bool
+	width    int
+	height   int
+
+	// Approvals waiting for a decision, oldest first. The queue is shared with
+	// the HTTP API, so entries can also disappear when answered elsewhere.
+	approvals      *approval.Queue
+	approvalEvents <-chan approval.Event
+	pending        []approval.Request
+	pendingIdx     int
+
+	// Argument editor for the selected approval, opened with E.
+	editing   bool
+	editID    string
+	editor    textarea.Model
+	editError string
 
 	// Viewports
 	logViewport    viewport.Model
@@ -60,40 +78,103 @@ type Model struct {
 	selectedIdx int
 	showDetails bool
 
+	// Standing approval grants, shown in the main pane instead of the logs.
+	showGrants bool
+	grants     []store.Grant
+	grantIdx   int
+	grantError string
+
 	// External Helpers
-	toolFetcher  func(upstream string) ([]ToolInfo, error)
-	currentTools []ToolInfo
-	fetchError   string
+	toolFetcher   func(upstream string) ([]ToolInfo, error)
+	healthFetcher func() map[string]HealthInfo
+	currentTools  []ToolInfo
+	fetchError    string
 }
 
-func InitialModel(cfg *config.Config, fetcher func(upstream string) ([]ToolInfo, error)) Model {
+func InitialModel(cfg *config.Config, fetcher func(upstream string) ([]ToolInfo, error), health func() map[string]HealthInfo, approvals *approval.Queue) Model {
 	var ups []UpstreamStatus
 	if cfg != nil {
 		for _, u := range cfg.Upstreams {
 			ups = append(ups, UpstreamStatus{
 				Name:   u.Name,
-				Status: "Running", // Assume running on start for now
+				Status: "Starting",
 				Config: u,
 			})
 		}
 	}
 
-	return Model{
-		logs:        []string{"System initialized. Waiting for traffic..."},
-		startTime:   time.Now(),
-		upstreams:   ups,
-		toolFetcher: fetcher,
-		selectedIdx: 0,
+	m := Model{
+		logs:          []string{"System initialized. Waiting for traffic..."},
+		startTime:     time.Now(),
+		upstreams:     ups,
+		toolFetcher:   fetcher,
+		healthFetcher: health,
+		selectedIdx:   0,
+		approvals:     approvals,
 		// Viewports initialized with default 0 size; resized on WindowSizeMsg
 		logViewport:    viewport.New(0, 0),
 		detailViewport: viewport.New(0, 0),
+		editor:         newArgsEditor(),
+	}
+	m.refreshHealth()
+	if approvals != nil {
+		// Subscribed for the lifetime of the program.
+		m.approvalEvents, _ = approvals.Subscribe()
+		m.pending = approvals.List()
+	}
+	return m
+}
+
+// refreshHealth pulls the latest supervisor state for every upstream. Upstreams
+// added or removed by a config reload are added to or dropped from the sidebar.
+func (m *Model) refreshHealth() {
+	if m.healthFetcher == nil {
+		return
+	}
+	health := m.healthFetcher()
+
+	seen := make(map[string]bool, len(m.upstreams))
+	kept := m.upstreams[:0]
+	for _, u := range m.upstreams {
+		h, ok := health[u.Name]
+		if !ok {
+			continue
+		}
+		u.Status = h.Status
+		u.Restarts = h.Restarts
+		u.LastError = h.LastError
+		u.Config = h.Config
+		kept = append(kept, u)
+		seen[u.Name] = true
+	}
+	var added []string
+	for name := range health {
+		if !seen[name] {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		h := health[name]
+		kept = append(kept, UpstreamStatus{
+			Name:      name,
+			Status:    h.Status,
+			Restarts:  h.Restarts,
+			LastError: h.LastError,
+			Config:    h.Config,
+		})
+	}
+	m.upstreams = kept
+
+	if m.selectedIdx >= len(m.upstreams) && len(m.upstreams) > 0 {
+		m.selectedIdx = len(m.upstreams) - 1
 	}
 }
 
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		waitForLog(),
-		waitForIntercept(),
+		waitForApproval(m.approvalEvents),
 		tickCmd(),
 	)
 }
@@ -129,28 +210,95 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if m.requestPending != nil {
+		if m.editing {
 			switch msg.String() {
+			case "esc":
+				m.closeEditor()
+				return m, nil
+			case "ctrl+s":
+				m.approveEdited()
+				return m, nil
+			case "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			}
+			var cmd tea.Cmd
+			m.editor, cmd = m.editor.Update(msg)
+			return m, cmd
+		}
+
+		if len(m.pending) > 0 {
+			switch msg.String() {
+			case "up", "k":
+				if m.pendingIdx > 0 {
+					m.pendingIdx--
+				}
+			case "down", "j":
+				if m.pendingIdx < len(m.pending)-1 {
+					m.pendingIdx++
+				}
 			case "y", "Y":
-				m.requestPending.ResponseChan <- true
-				m.requestPending = nil
-				return m, waitForIntercept()
+				m.resolve(m.pending[m.pendingIdx].ID, true)
 			case "n", "N":
-				m.requestPending.ResponseChan <- false
-				m.requestPending = nil
-				return m, waitForIntercept()
+				m.resolve(m.pending[m.pendingIdx].ID, false)
+			case "a", "A":
+				for _, req := range m.pending {
+					m.resolve(req.ID, true)
+				}
+			case "t", "T":
+				m.approveWithGrant(m.pending[m.pendingIdx].ID, approval.GrantTool)
+			case "h", "H":
+				m.approveWithGrant(m.pending[m.pendingIdx].ID, approval.GrantHour)
+			case "i", "I":
+				m.approveWithGrant(m.pending[m.pendingIdx].ID, approval.GrantArgs)
+			case "e", "E":
+				return m, m.openEditor(m.pending[m.pendingIdx])
+			case "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
 			}
 			return m, nil
 		}
 
+		if m.showGrants {
+			switch msg.String() {
+			case "up", "k":
+				if m.grantIdx > 0 {
+					m.grantIdx--
+				}
+				return m, nil
+			case "down", "j":
+				if m.grantIdx < len(m.grants)-1 {
+					m.grantIdx++
+				}
+				return m, nil
+			case "x", "delete":
+				if m.grantIdx < len(m.grants) {
+					m.revokeGrant(m.grants[m.grantIdx].ID)
+				}
+				return m, nil
+			case "esc":
+				m.showGrants = false
+				return m, nil
+			}
+		}
+
 		// Global keys
 		switch msg.String() {
 		case "q", "ctrl+c":
 			m.quitting = true
 			return m, tea.Quit
+		case "g":
+			m.showGrants = !m.showGrants
+			if m.showGrants {
+				m.showDetails = false
+				m.refreshGrants()
+			}
+			return m, nil
 		case "enter", "space":
+			m.showGrants = false
 			m.showDetails = !m.showDetails
-			if m.showDetails {
+			if m.showDetails && m.selectedIdx < len(m.upstreams) {
 				return m, m.fetchToolsCmd(m.upstreams[m.selectedIdx].Name)
 			}
 		case "pgup":
@@ -244,6 +392,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.detailViewport.SetContent(m.renderDetailContent())
 
 	case tickMsg:
+		m.refreshHealth()
+		// Events are dropped when the TUI falls behind, so re-read the queue
+		// rather than trust the last event to have arrived.
+		m.refreshPending()
+		if m.showDetails {
+			m.detailViewport.SetContent(m.renderDetailContent())
+		}
+		if m.showGrants {
+			// Picks up grants added from the modal or over HTTP, and drops expired ones.
+			m.refreshGrants()
+		}
 		cmds = append(cmds, tickCmd())
 
 	case toolsFetchedMsg:
@@ -291,17 +450,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		cmds = append(cmds, waitForLog())
 
-	case InterceptRequest:
-		m.requestPending = &msg
-		// Update stats for this upstream (simple finder)
-		for i, u := range m.upstreams {
-			if u.Name == msg.Upstream {
-				m.upstreams[i].CallCount++
-				m.upstreams[i].LastCall = time.Now()
+	case approval.Event:
+		if msg.Type == approval.EventPending {
+			// Update stats for this upstream (simple finder)
+			for i, u := range m.upstreams {
+				if u.Name == msg.Request.Upstream {
+					m.upstreams[i].CallCount++
+					m.upstreams[i].LastCall = time.Now()
+				}
 			}
+			// Refresh details because stats changed
+			m.detailViewport.SetContent(m.renderDetailContent())
+		}
+		m.refreshPending()
+		cmds = append(cmds, waitForApproval(m.approvalEvents))
+
+	default:
+		// Cursor blinks for the argument editor.
+		if m.editing {
+			var cmd tea.Cmd
+			m.editor, cmd = m.editor.Update(msg)
+			cmds = append(cmds, cmd)
 		}
-		// Refresh details because stats changed
-		m.detailViewport.SetContent(m.renderDetailContent())
 	}
 
 	return m, tea.Batch(cmds...)
@@ -312,7 +482,7 @@ func (m Model) View() string {
 		return ""
 	}
 
-	if m.requestPending != nil {
+	if len(m.pending) > 0 {
 		return m.renderInterceptModal()
 	}
 
@@ -320,7 +490,9 @@ func (m Model) View() string {
 	sidebar := m.renderSidebar()
 
 	var mainPane string
-	if m.showDetails {
+	if m.showGrants {
+		mainPane = styleLogPane.Width(m.logViewport.Width).Height(m.logViewport.Height).Render(m.renderGrants())
+	} else if m.showDetails {
 		mainPane = styleLogPane.Width(m.detailViewport.Width).Render(m.detailViewport.View())
 	} else {
 		mainPane = styleLogPane.Width(m.logViewport.Width).Render(m.logViewport.View())
@@ -345,7 +517,9 @@ func (m Model) renderHeader() string {
 	status := fmt.Sprintf("UPTIME: %s  |  PORT: 8080", uptime)
 
 	mode := "LOG MONITOR"
-	if m.showDetails {
+	if m.showGrants {
+		mode = "APPROVAL GRANTS"
+	} else if m.showDetails {
 		mode = "DETAIL INSPECTOR"
 	}
 	right := lipgloss.NewStyle().Foreground(cForeground).Render(mode)
@@ -369,9 +543,14 @@ func (m Model) renderSidebar() string {
 	s := styleSidebarHeader.Render("UPSTREAMS") + "\n\n"
 
 	for i, u := range m.upstreams {
-		icon := styleStatusRunning
-		if u.Status != "Running" {
-			icon = styleStatusStopped
+		icon := styleStatusStopped
+		switch u.Status {
+		case "Running":
+			icon = styleStatusRunning
+		case "Restarting":
+			icon = styleStatusRestarting
+		case "Failed":
+			icon = styleStatusError
 		}
 
 		name := u.Name
@@ -392,7 +571,7 @@ func (m Model) renderSidebar() string {
 		s += line + "\n"
 	}
 
-	s += "\n\n" + lipgloss.NewStyle().Foreground(cComment).Italic(true).Render("Use ↑/↓ to nav\nEnter for details")
+	s += "\n\n" + lipgloss.NewStyle().Foreground(cComment).Italic(true).Render("Use ↑/↓ to nav\nEnter for details\nG for grants")
 
 	return styleSidebar.Render(s)
 }
@@ -415,6 +594,10 @@ func (m Model) renderDetailContent() string {
 
 	// Stats
 	s += fmt.Sprintf("State:      %s\n", u.Status)
+	s += fmt.Sprintf("Restarts:   %d\n", u.Restarts)
+	if u.LastError != "" {
+		s += fmt.Sprintf("Last Error: %s\n", lipgloss.NewStyle().Foreground(cDanger).Render(u.LastError))
+	}
 	s += fmt.Sprintf("Calls:      %d\n", u.CallCount)
 	s += fmt.Sprintf("Last Call:  %s\n\n", u.LastCall.Format("15:04:05"))
 
@@ -423,10 +606,15 @@ func (m Model) renderDetailContent() string {
 	vStyle := lipgloss.NewStyle().Foreground(cForeground)
 
 	s += lipgloss.NewStyle().Foreground(cForeground).Bold(true).Render("CONFIGURATION:") + "\n"
-	s += fmt.Sprintf("%s %s\n", kStyle.Render("Command:"), vStyle.Render(u.Config.Command))
-	s += fmt.Sprintf("%s    %s\n", kStyle.Render("Args:"), vStyle.Render(strings.Join(u.Config.Args, " ")))
+	s += fmt.Sprintf("%s %s\n", kStyle.Render("Transport:"), vStyle.Render(u.Config.Transport))
+	if u.Config.URL != "" {
+		s += fmt.Sprintf("%s     %s\n", kStyle.Render("URL:"), vStyle.Render(u.Config.URL))
+	} else {
+		s += fmt.Sprintf("%s   %s\n", kStyle.Render("Command:"), vStyle.Render(u.Config.Command))
+		s += fmt.Sprintf("%s      %s\n", kStyle.Render("Args:"), vStyle.Render(strings.Join(u.Config.Args, " ")))
+	}
 	if u.Config.Workdir != "" {
-		s += fmt.Sprintf("%s %s\n", kStyle.Render("Workdir:"), vStyle.Render(u.Config.Workdir))
+		s += fmt.Sprintf("%s   %s\n", kStyle.Render("Workdir:"), vStyle.Render(u.Config.Workdir))
 	}
 	s += "\n"
 
@@ -460,24 +648,45 @@ func (m Model) renderDetailContent() string {
 }
 
 func (m Model) renderInterceptModal() string {
-	req := m.requestPending
-
-	header := styleModalHeader.Render("⚠️  INTERCEPTION REQUIRED ⚠️")
+	header := styleModalHeader.Render(fmt.Sprintf("⚠️  INTERCEPTION REQUIRED (%d PENDING) ⚠️", len(m.pending)))
+
+	// Queue overview with countdowns; the View is redrawn on every tick.
+	var list strings.Builder
+	for i, req := range m.pending {
+		line := fmt.Sprintf("#%s %s/%s  %s", req.ID, req.Upstream, req.Tool, countdown(req))
+		if i == m.pendingIdx {
+			list.WriteString(lipgloss.NewStyle().Foreground(cHighlight).Bold(true).Render("> "+line) + "\n")
+		} else {
+			list.WriteString(styleUpstreamItem.Render("  "+line) + "\n")
+		}
+	}
 
 	// Industrial detail view
+	req := m.pending[m.pendingIdx]
 	kStyle := styleKeyParams
 	vStyle := lipgloss.NewStyle().Foreground(cForeground)
 
 	details := fmt.Sprintf(
-		"%s %s\n%s     %s\n%s     %s\n",
+		"%s %s\n%s     %s\n",
 		kStyle.Render("UPSTREAM:"), vStyle.Render(req.Upstream),
 		kStyle.Render("TOOL:"), vStyle.Render(req.Tool),
-		kStyle.Render("ARGS:"), vStyle.Render(req.Args),
 	)
 
-	question := "\n" + lipgloss.NewStyle().Bold(true).Render("ALLOW EXECUTION? (Y/N)")
+	var question string
+	if m.editing {
+		details += kStyle.Render("EDIT ARGS:") + "\n" + m.editor.View() + "\n"
+		if m.editError != "" {
+			details += lipgloss.NewStyle().Foreground(cDanger).Render(m.editError) + "\n"
+		}
+		question = "\n" + lipgloss.NewStyle().Foreground(cComment).Render("Ctrl+S validate and approve · Esc cancel")
+	} else {
+		details += fmt.Sprintf("%s     %s\n", kStyle.Render("ARGS:"), vStyle.Render(req.Arguments))
+		question = "\n" + lipgloss.NewStyle().Bold(true).Render("ALLOW EXECUTION? (Y/N)") + "\n" +
+			lipgloss.NewStyle().Foreground(cComment).Render("↑/↓ select · Y approve · N deny · A approve all · E edit arguments") + "\n" +
+			lipgloss.NewStyle().Foreground(cComment).Render("Always allow: T this tool · H this tool for 1 hour · I identical arguments")
+	}
 
-	box := styleModalBox.Render(header + "\n\n" + details + question)
+	box := styleModalBox.Render(header + "\n\n" + list.String() + "\n" + details + question)
 
 	return lipgloss.Place(m.width, m.height,
 		lipgloss.Center, lipgloss.Center,
@@ -485,6 +694,175 @@ func (m Model) renderInterceptModal() string {
 	)
 }
 
+// countdown renders the time left before a request's default decision applies.
+func countdown(req approval.Request) string {
+	if req.ExpiresAt == nil {
+		return ""
+	}
+	left := time.Until(*req.ExpiresAt).Round(time.Second)
+	if left < 0 {
+		left = 0
+	}
+	return fmt.Sprintf("⏱ %s", left)
+}
+
+// approverTUI names decisions made in the TUI in the audit log.
+const approverTUI = "tui"
+
+// resolve answers a pending request. It may already have been answered through
+// the HTTP API, in which case the first answer stands.
+func (m *Model) resolve(id string, approve bool) {
+	_ = m.approvals.Resolve(id, approve, approverTUI)
+	m.refreshPending()
+}
+
+func newArgsEditor() textarea.Model {
+	ta := textarea.New()
+	ta.SetWidth(60)
+	ta.SetHeight(10)
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+	return ta
+}
+
+// openEditor starts editing the arguments of req, pretty-printed.
+func (m *Model) openEditor(req approval.Request) tea.Cmd {
+	text := req.Arguments
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(req.Arguments), "", "  "); err == nil {
+		text = buf.String()
+	}
+	m.editing, m.editID, m.editError = true, req.ID, ""
+	m.editor.SetValue(text)
+	return m.editor.Focus()
+}
+
+func (m *Model) closeEditor() {
+	m.editing, m.editID, m.editError = false, "", ""
+	m.editor.Blur()
+	m.editor.Reset()
+}
+
+// approveEdited submits the edited arguments. Validation failures keep the
+// editor open so the reviewer can fix them.
+func (m *Model) approveEdited() {
+	err := m.approvals.ApproveEdited(m.editID, m.editor.Value(), approverTUI)
+	var invalid schema.Errors
+	switch {
+	case err == nil, errors.Is(err, approval.ErrNotFound):
+		m.closeEditor()
+		m.refreshPending()
+	case errors.As(err, &invalid):
+		lines := make([]string, len(invalid))
+		for i, e := range invalid {
+			lines[i] = "✗ " + e.Error()
+		}
+		m.editError = strings.Join(lines, "\n")
+	default:
+		m.editError = "✗ " + err.Error()
+	}
+}
+
+func (m Model) isPending(id string) bool {
+	for _, req := range m.pending {
+		if req.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// approveWithGrant approves a request and remembers the decision for later
+// calls; see approval.ApproveWithGrant.
+func (m *Model) approveWithGrant(id, scope string) {
+	if err := m.approvals.ApproveWithGrant(id, scope, approverTUI); err != nil && !errors.Is(err, approval.ErrNotFound) {
+		// The grant could not be saved; fall back to a one-off approval.
+		logger.Global.Warn("Failed to save approval grant", zap.String("id", id), zap.Error(err))
+		_ = m.approvals.Resolve(id, true, approverTUI)
+	}
+	m.refreshPending()
+	if m.showGrants {
+		m.refreshGrants()
+	}
+}
+
+func (m *Model) refreshGrants() {
+	grants, err := store.ListGrants()
+	if err != nil {
+		m.grantError = err.Error()
+		return
+	}
+	m.grants, m.grantError = grants, ""
+	if m.grantIdx >= len(m.grants) {
+		m.grantIdx = max(len(m.grants)-1, 0)
+	}
+}
+
+func (m *Model) revokeGrant(id int64) {
+	if _, err := store.RevokeGrant(id); err != nil {
+		m.grantError = err.Error()
+		return
+	}
+	logger.Global.Info("Approval grant revoked", zap.Int64("id", id))
+	m.refreshGrants()
+}
+
+func (m Model) renderGrants() string {
+	s := lipgloss.NewStyle().Foreground(cAccent).Bold(true).Underline(true).Render("APPROVAL GRANTS") + "\n\n"
+
+	if m.grantError != "" {
+		s += lipgloss.NewStyle().Foreground(cDanger).Render("Error: "+m.grantError) + "\n\n"
+	}
+	if len(m.grants) == 0 {
+		s += lipgloss.NewStyle().Foreground(cComment).Render("No grants. Press T, H or I when approving a call to add one.") + "\n"
+	}
+	for i, g := range m.grants {
+		expires := "always"
+		if g.ExpiresAt != nil {
+			expires = "for " + time.Until(*g.ExpiresAt).Round(time.Second).String()
+		}
+		args := "any arguments"
+		if g.Arguments != "" {
+			args = g.Arguments
+		}
+		line := fmt.Sprintf("#%d %s/%s  %s  %s", g.ID, g.Upstream, g.Tool, expires, args)
+		if i == m.grantIdx {
+			s += lipgloss.NewStyle().Foreground(cHighlight).Bold(true).Render("> "+line) + "\n"
+		} else {
+			s += styleUpstreamItem.Render("  "+line) + "\n"
+		}
+	}
+
+	s += "\n" + lipgloss.NewStyle().Foreground(cComment).Italic(true).Render("↑/↓ select · X revoke · G/Esc back to logs")
+	return s
+}
+
+// refreshPending re-reads the queue, keeping the selection on the same request when possible.
+func (m *Model) refreshPending() {
+	if m.approvals == nil {
+		return
+	}
+	var selected string
+	if m.pendingIdx < len(m.pending) {
+		selected = m.pending[m.pendingIdx].ID
+	}
+	m.pending = m.approvals.List()
+	if m.editing && !m.isPending(m.editID) {
+		// Answered elsewhere, expired or withdrawn while being edited.
+		m.closeEditor()
+	}
+	for i, req := range m.pending {
+		if req.ID == selected {
+			m.pendingIdx = i
+			return
+		}
+	}
+	// The selected request is gone; stay at the same position.
+	if m.pendingIdx >= len(m.pending) {
+		m.pendingIdx = max(len(m.pending)-1, 0)
+	}
+}
+
 // Commands
 func waitForLog() tea.Cmd {
 	return func() tea.Msg {
@@ -492,8 +870,15 @@ func waitForLog() tea.Cmd {
 	}
 }
 
-func waitForIntercept() tea.Cmd {
+func waitForApproval(events <-chan approval.Event) tea.Cmd {
+	if events == nil {
+		return nil
+	}
 	return func() tea.Msg {
-		return <-InterceptChan
+		ev, ok := <-events
+		if !ok {
+			return nil
+		}
+		return ev
 	}
 }
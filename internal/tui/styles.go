@@ -52,9 +52,10 @@ var (
 	styleUpstreamItem = lipgloss.NewStyle().
 				PaddingLeft(1)
 
-	styleStatusRunning = lipgloss.NewStyle().Foreground(cSuccess).SetString("●")
-	styleStatusStopped = lipgloss.NewStyle().Foreground(cComment).SetString("○")
-	styleStatusError   = lipgloss.NewStyle().Foreground(cDanger).SetString("✖")
+	styleStatusRunning    = lipgloss.NewStyle().Foreground(cSuccess).SetString("●")
+	styleStatusStopped    = lipgloss.NewStyle().Foreground(cComment).SetString("○")
+	styleStatusRestarting = lipgloss.NewStyle().Foreground(cWarning).SetString("◐")
+	styleStatusError      = lipgloss.NewStyle().Foreground(cDanger).SetString("✖")
 
 	// Main Content (Logs)
 	styleLogPane = lipgloss.NewStyle().
@@ -1,6 +1,6 @@
 
 import { useState, useEffect } from 'react';
-import { Terminal, Database, Wrench, RefreshCw, AlertCircle, FileText, ChevronRight, ChevronDown, Monitor } from 'lucide-react';
+import { Terminal, Database, Wrench, RefreshCw, AlertCircle, FileText, ChevronRight, ChevronDown, Monitor, ShieldCheck, Check, X } from 'lucide-react';
 import './App.css';
 
 const API_BASE = "http://localhost:8080";
@@ -11,8 +11,10 @@ function App() {
   const [resources, setResources] = useState([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
-  const [activeTab, setActiveTab] = useState('tools'); // 'tools' | 'resources'
+  const [activeTab, setActiveTab] = useState('tools'); // 'tools' | 'resources' | 'approvals'
   const [expandedItems, setExpandedItems] = useState({});
+  const [approvals, setApprovals] = useState([]);
+  const [approvalError, setApprovalError] = useState(null);
 
   const fetchData = async () => {
     setLoading(true);
@@ -44,8 +46,48 @@ function App() {
     }
   };
 
+  // Pending approvals are shared with the TUI and CLI; whoever answers first wins.
+  const fetchApprovals = async () => {
+    try {
+      const res = await fetch(`${API_BASE}/approvals`, {
+        headers: { "Authorization": `Bearer ${AUTH_TOKEN}` }
+      });
+      if (!res.ok) throw new Error(`Approvals API Error: ${res.status}`);
+      const data = await res.json();
+      setApprovals(data.approvals || []);
+      setApprovalError(null);
+    } catch (err) {
+      console.error(err);
+      setApprovalError(err.message);
+    }
+  };
+
+  const resolveApproval = async (id, decision) => {
+    try {
+      const res = await fetch(`${API_BASE}/approvals/${encodeURIComponent(id)}/${decision}`, {
+        method: "POST",
+        headers: { "Authorization": `Bearer ${AUTH_TOKEN}` }
+      });
+      // 404 means it was already decided elsewhere or the caller went away.
+      if (!res.ok && res.status !== 404) throw new Error(`Approvals API Error: ${res.status}`);
+    } catch (err) {
+      console.error(err);
+      setApprovalError(err.message);
+    } finally {
+      fetchApprovals();
+    }
+  };
+
   useEffect(() => {
     fetchData();
+    fetchApprovals();
+
+    // EventSource cannot send headers, so the token goes in the query string.
+    const events = new EventSource(`${API_BASE}/approvals/events?access_token=${encodeURIComponent(AUTH_TOKEN)}`);
+    ['pending', 'approved', 'denied', 'withdrawn', 'expired'].forEach(type => {
+      events.addEventListener(type, fetchApprovals);
+    });
+    return () => events.close();
   }, []);
 
   const toggleExpand = (id) => {
@@ -96,6 +138,13 @@ function App() {
               <Database className="icon" /> Resources
               <span className="badge">{resources.length}</span>
             </button>
+            <button
+              className={`nav-item ${activeTab === 'approvals' ? 'active' : ''}`}
+              onClick={() => setActiveTab('approvals')}
+            >
+              <ShieldCheck className="icon" /> Approvals
+              <span className={`badge ${approvals.length > 0 ? 'badge-alert' : ''}`}>{approvals.length}</span>
+            </button>
           </nav>
         </aside>
 
@@ -171,6 +220,43 @@ function App() {
               ))}
             </div>
           )}
+
+          {activeTab === 'approvals' && (
+            <div className="section">
+              <h2>Pending Approvals</h2>
+              {approvalError && (
+                <div className="error-banner">
+                  <AlertCircle className="icon" />
+                  {approvalError}
+                </div>
+              )}
+              {approvals.length === 0 && (
+                <p className="empty-state">No tool calls are waiting for approval.</p>
+              )}
+              <div className="list">
+                {approvals.map((req) => (
+                  <div key={req.id} className="list-item approval-item">
+                    <div className="list-item-info">
+                      <span className="res-name">#{req.id} {req.upstream}/{req.tool}</span>
+                      <span className="res-uri">
+                        Requested {new Date(req.created_at).toLocaleTimeString()}
+                        {req.expires_at && ` · default decision at ${new Date(req.expires_at).toLocaleTimeString()}`}
+                      </span>
+                      <pre className="approval-args">{req.arguments}</pre>
+                    </div>
+                    <div className="approval-actions">
+                      <button className="approve-btn" onClick={() => resolveApproval(req.id, 'approve')}>
+                        <Check className="icon-sm" /> Approve
+                      </button>
+                      <button className="deny-btn" onClick={() => resolveApproval(req.id, 'deny')}>
+                        <X className="icon-sm" /> Deny
+                      </button>
+                    </div>
+                  </div>
+                ))}
+              </div>
+            </div>
+          )}
         </main>
       </div>
     </div>